@@ -2,21 +2,38 @@ package app
 
 import (
 	"Calendar/internal/config"
+	"Calendar/internal/eventbus"
+	"Calendar/internal/metrics"
+	"Calendar/internal/models"
+	"Calendar/internal/notifier"
 	"Calendar/internal/repository"
+	"Calendar/internal/rpc"
+	"Calendar/internal/scheduler"
 	"Calendar/internal/service"
 	"Calendar/internal/transport"
 	"Calendar/pkg/logger"
 	"Calendar/pkg/postgres"
 	"context"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 type App struct {
 	SubscriptionServer *transport.CalendarServer
+	GRPCServer         *rpc.CalendarServer
+	ReminderWorker     *scheduler.ReminderWorker
+	WebhookWorker      *scheduler.WebhookWorker
+	OutboxRelay        *scheduler.OutboxRelay
+	IdempotencyPurger  *scheduler.Purger
+	TrashPurger        *scheduler.Purger
+	EventBus           eventbus.EventBus
 	cfg                *config.Config
 	ctx                context.Context
 	wg                 sync.WaitGroup
@@ -28,13 +45,45 @@ func New(ctx context.Context, cfg *config.Config) *App {
 	if err != nil {
 		panic(err)
 	}
-	repo := repository.NewCalendarRepository(ctx, db)
-	srv := service.NewCalendarService(ctx, repo)
-	server := transport.NewCalendarServer(ctx, cfg, srv)
+	runCtx, cancel := context.WithCancel(ctx)
+	prometheus.MustRegister(metrics.NewPoolCollector(db))
+	repo := repository.NewMeasuredRepository(repository.NewCalendarRepository(ctx, db, cfg.Quota.MaxEvents))
+	srv := service.NewCalendarService(ctx, cfg.Quota, repo)
+	webhookRepo := repository.NewWebhookRepository(ctx, db)
+	webhooks := service.NewWebhookService(ctx, webhookRepo)
+	bus := eventbus.NewInMemoryBus()
+	outboxRepo := repository.NewOutboxRepository(ctx, db)
+	changes := service.NewChangeService(ctx, outboxRepo, bus)
+	idempotencyRepo := repository.NewIdempotencyRepository(ctx, db)
+	idempotency := service.NewIdempotencyService(ctx, cfg.Idempotency, idempotencyRepo)
+	server := transport.NewCalendarServer(ctx, cfg, srv, webhooks, changes, idempotency)
+	grpcServer := rpc.NewCalendarServer(ctx, cfg, srv, changes)
+	dispatcher := notifier.NewDispatcher(map[string]notifier.Notifier{
+		models.ChannelLog:     notifier.NewLogNotifier(),
+		models.ChannelWebhook: notifier.NewWebhookNotifier(10 * time.Second),
+		models.ChannelEmail:   notifier.NewSMTPNotifier(cfg.SMTP),
+	})
+	reminderRepo := repository.NewReminderRepository(ctx, db)
+	worker := scheduler.NewReminderWorker(runCtx, cfg.Scheduler, reminderRepo, dispatcher)
+	webhookWorker := scheduler.NewWebhookWorker(runCtx, cfg.Webhooks, webhookRepo)
+	bus.Subscribe("webhooks", webhookWorker.Enqueue)
+	relay := scheduler.NewOutboxRelay(runCtx, cfg.Outbox, outboxRepo, bus)
+	idempotencyPurger := scheduler.NewPurger(runCtx, "idempotency keys", cfg.IdempotencyPurge, idempotencyRepo.DeleteExpired)
+	trashPurger := scheduler.NewPurger(runCtx, "trash", cfg.TrashPurge, func(limit int) (int64, error) {
+		return repo.PurgeTrash(cfg.TrashRetention, limit)
+	})
 	return &App{
 		SubscriptionServer: server,
+		GRPCServer:         grpcServer,
+		ReminderWorker:     worker,
+		WebhookWorker:      webhookWorker,
+		OutboxRelay:        relay,
+		IdempotencyPurger:  idempotencyPurger,
+		TrashPurger:        trashPurger,
+		EventBus:           bus,
 		cfg:                cfg,
-		ctx:                ctx,
+		ctx:                runCtx,
+		cancel:             cancel,
 	}
 }
 
@@ -45,7 +94,7 @@ func (s *App) MustRun() {
 }
 
 func (s *App) Run() error {
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 2)
 	s.wg.Add(1)
 	go func() {
 		logger.GetLoggerFromCtx(s.ctx).Info("Server started on address", zap.Any("address", s.cfg.Host+":"+s.cfg.Port))
@@ -55,15 +104,67 @@ func (s *App) Run() error {
 			s.cancel()
 		}
 	}()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.GRPCServer.Run(); err != nil {
+			errCh <- err
+			s.cancel()
+		}
+	}()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.ReminderWorker.Run()
+	}()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.WebhookWorker.Run()
+	}()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.OutboxRelay.Run()
+	}()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.IdempotencyPurger.Run()
+	}()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.TrashPurger.Run()
+	}()
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	select {
 	case err := <-errCh:
 		logger.GetLoggerFromCtx(s.ctx).Error("error running app", zap.Error(err))
+		s.stop()
 		return err
+	case <-sigCh:
+		logger.GetLoggerFromCtx(s.ctx).Info("shutdown signal received")
 	case <-s.ctx.Done():
 		logger.GetLoggerFromCtx(s.ctx).Info("context done")
 	}
+	s.stop()
 
 	return nil
 }
+
+// stop shuts the HTTP and gRPC servers down first so that no new jobs are scheduled,
+// then cancels the background workers and waits for them to finish.
+func (s *App) stop() {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(s.ctx), shutdownTimeout)
+	defer cancel()
+	if err := s.SubscriptionServer.Shutdown(ctx); err != nil {
+		logger.GetLoggerFromCtx(s.ctx).Error("error shutting down server", zap.Error(err))
+	}
+	if err := s.GRPCServer.Shutdown(ctx); err != nil {
+		logger.GetLoggerFromCtx(s.ctx).Error("error shutting down grpc server", zap.Error(err))
+	}
+	s.cancel()
+	s.wg.Wait()
+}
@@ -1,15 +1,33 @@
 package config
 
 import (
+	"Calendar/internal/notifier"
+	"Calendar/internal/ratelimit"
+	"Calendar/internal/scheduler"
+	"Calendar/internal/service"
 	"Calendar/pkg/postgres"
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
+	"time"
 )
 
 type Config struct {
-	Postgres postgres.Config `yaml:"Postgres"`
-	Port     string          `yaml:"port" env-default:"4047"`
-	Host     string          `yaml:"host" env-default:"0.0.0.0"`
+	Postgres         postgres.Config           `yaml:"Postgres"`
+	Scheduler        scheduler.Config          `yaml:"scheduler"`
+	Webhooks         scheduler.WebhookConfig   `yaml:"webhooks"`
+	Outbox           scheduler.RelayConfig     `yaml:"outbox"`
+	SMTP             notifier.SMTPConfig       `yaml:"smtp"`
+	Idempotency      service.IdempotencyConfig `yaml:"idempotency"`
+	IdempotencyPurge scheduler.PurgeConfig     `yaml:"idempotency_purge" env-prefix:"IDEMPOTENCY_PURGE_"`
+	TrashRetention   time.Duration             `yaml:"trash_retention" env:"TRASH_RETENTION" env-default:"720h"`
+	TrashPurge       scheduler.PurgeConfig     `yaml:"trash_purge" env-prefix:"TRASH_PURGE_"`
+	RateLimit        ratelimit.Config          `yaml:"rate_limit" env-prefix:"RATE_LIMIT_"`
+	Quota            service.QuotaConfig       `yaml:"quota" env-prefix:"QUOTA_"`
+	AdminKeys        []string                  `yaml:"admin_keys" env:"ADMIN_KEYS"`
+	TrustedProxies   []string                  `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	Port             string                    `yaml:"port" env-default:"4047"`
+	GRPCPort         string                    `yaml:"grpc_port" env-default:"4049"`
+	Host             string                    `yaml:"host" env-default:"0.0.0.0"`
 }
 
 func NewConfig() (*Config, error) {
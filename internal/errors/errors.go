@@ -27,3 +27,56 @@ type BusinessError struct {
 func (e *BusinessError) Error() string {
 	return fmt.Sprintf("business error: %s", e.Message)
 }
+
+// PreconditionFailedError reports that the resource changed since the client
+// read it. Version is the current version of the resource.
+type PreconditionFailedError struct {
+	Resource string
+	ID       string
+	Version  int64
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("%s with id %s was modified, current version is %d", e.Resource, e.ID, e.Version)
+}
+
+// PreconditionRequiredError reports that the client didn't say which version
+// of the resource it expects.
+type PreconditionRequiredError struct {
+	Resource string
+	ID       string
+}
+
+func (e *PreconditionRequiredError) Error() string {
+	return fmt.Sprintf("%s with id %s can only be changed with If-Match or version", e.Resource, e.ID)
+}
+
+// AbortedError reports an operation of an atomic batch that wasn't applied
+// because the operation at Index failed.
+type AbortedError struct {
+	Index int
+}
+
+func (e *AbortedError) Error() string {
+	return fmt.Sprintf("not applied, operation %d of the batch failed", e.Index)
+}
+
+// ConflictError reports a request that clashes with an earlier one.
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflict: %s", e.Message)
+}
+
+// QuotaExceededError reports a change that would take the user over the
+// limit of a resource.
+type QuotaExceededError struct {
+	Resource string
+	Limit    int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: at most %d %s allowed", e.Limit, e.Resource)
+}
@@ -0,0 +1,66 @@
+package eventbus
+
+import (
+	"Calendar/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type Handler func(ctx context.Context, event *models.DomainEvent) error
+
+type EventBus interface {
+	Publish(ctx context.Context, event *models.DomainEvent) error
+	Subscribe(name string, handler Handler) (unsubscribe func())
+}
+
+// InMemoryBus calls every subscriber synchronously in the publishing
+// goroutine. Handlers may see the same event more than once when a publish is
+// retried, so they must be idempotent.
+type InMemoryBus struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[int]subscription
+}
+
+type subscription struct {
+	name    string
+	handler Handler
+}
+
+func NewInMemoryBus() *InMemoryBus {
+	return &InMemoryBus{
+		handlers: make(map[int]subscription),
+	}
+}
+
+func (b *InMemoryBus) Subscribe(name string, handler Handler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = subscription{name: name, handler: handler}
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+func (b *InMemoryBus) Publish(ctx context.Context, event *models.DomainEvent) error {
+	b.mu.RLock()
+	subs := make([]subscription, 0, len(b.handlers))
+	for _, sub := range b.handlers {
+		subs = append(subs, sub)
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, sub := range subs {
+		if err := sub.handler(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sub.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,47 @@
+package eventbus
+
+import (
+	"Calendar/internal/models"
+	"context"
+	"sync"
+)
+
+// Subscription buffers the events accepted by its filter for a single
+// consumer. A consumer that falls more than the buffer behind is dropped:
+// Lagged is closed and no more events are delivered, so a slow client never
+// blocks the publisher and has to resume from the change log instead.
+type Subscription struct {
+	C           <-chan *models.DomainEvent
+	Lagged      <-chan struct{}
+	ch          chan *models.DomainEvent
+	lagged      chan struct{}
+	once        sync.Once
+	unsubscribe func()
+}
+
+func NewSubscription(bus EventBus, name string, buffer int, filter func(event *models.DomainEvent) bool) *Subscription {
+	ch := make(chan *models.DomainEvent, buffer)
+	lagged := make(chan struct{})
+	sub := &Subscription{
+		C:      ch,
+		Lagged: lagged,
+		ch:     ch,
+		lagged: lagged,
+	}
+	sub.unsubscribe = bus.Subscribe(name, func(ctx context.Context, event *models.DomainEvent) error {
+		if !filter(event) {
+			return nil
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			sub.once.Do(func() { close(sub.lagged) })
+		}
+		return nil
+	})
+	return sub
+}
+
+func (s *Subscription) Close() {
+	s.unsubscribe()
+}
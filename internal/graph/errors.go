@@ -0,0 +1,61 @@
+package graph
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/pkg/logger"
+	"context"
+	errors1 "errors"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+// queryError carries the code of a service error in the extensions of the
+// GraphQL error, like the error field of ErrorResponse over HTTP.
+type queryError struct {
+	message    string
+	extensions map[string]any
+}
+
+func (e *queryError) Error() string {
+	return e.message
+}
+
+func (e *queryError) Extensions() map[string]any {
+	return e.extensions
+}
+
+func (r *resolver) resolverError(err error) error {
+	var validationErr *errors.ValidationError
+	var notFoundErr *errors.NotFoundError
+	var preconditionErr *errors.PreconditionFailedError
+	var requiredErr *errors.PreconditionRequiredError
+	var quotaErr *errors.QuotaExceededError
+	var businessErr *errors.BusinessError
+
+	switch {
+	case errors1.As(err, &validationErr):
+		return &queryError{validationErr.Error(), map[string]any{"code": "validation_error", "field": validationErr.Field}}
+	case errors1.As(err, &notFoundErr):
+		return &queryError{notFoundErr.Error(), map[string]any{"code": "not_found"}}
+	case errors1.As(err, &preconditionErr):
+		return &queryError{preconditionErr.Error(), map[string]any{"code": "precondition_failed", "version": strconv.FormatInt(preconditionErr.Version, 10)}}
+	case errors1.As(err, &requiredErr):
+		return &queryError{requiredErr.Error(), map[string]any{"code": "precondition_required"}}
+	case errors1.As(err, &quotaErr):
+		return &queryError{quotaErr.Error(), map[string]any{"code": "quota_exceeded", "limit": strconv.Itoa(quotaErr.Limit)}}
+	case errors1.As(err, &businessErr):
+		return &queryError{businessErr.Error(), map[string]any{"code": "business_error"}}
+	default:
+		logger.GetLoggerFromCtx(r.ctx).Error("Internal server error", zap.Any("error", err))
+		return &queryError{"An unexpected error occurred", map[string]any{"code": "internal_server_error"}}
+	}
+}
+
+// panicLogger logs panics of resolvers, which graphql-go turns into errors.
+type panicLogger struct {
+	ctx context.Context
+}
+
+func (l *panicLogger) LogPanic(_ context.Context, value any) {
+	logger.GetLoggerFromCtx(l.ctx).Error("panic in graphql resolver", zap.Any("panic", value), zap.Stack("stack"))
+}
@@ -0,0 +1,200 @@
+// Package graph serves the calendar over GraphQL. It goes through the same
+// service as the HTTP and gRPC APIs and batches the repository calls of
+// nested fields per request.
+package graph
+
+import (
+	"Calendar/internal/models"
+	"Calendar/internal/service"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"github.com/graph-gophers/graphql-go"
+	"maps"
+	"net/http"
+	"strings"
+)
+
+const (
+	maxCalendarsPerQuery = 50
+	eventsPageSize       = "500"
+	maxEventsPerCalendar = 500
+	maxCalendars         = 50
+)
+
+//go:embed schema.graphql
+var schemaString string
+
+type Handler struct {
+	srv    service.CalendarServiceInterface
+	schema *graphql.Schema
+}
+
+func NewHandler(ctx context.Context, srv service.CalendarServiceInterface) *Handler {
+	return &Handler{
+		srv: srv,
+		schema: graphql.MustParseSchema(schemaString, &resolver{ctx: ctx, srv: srv},
+			graphql.UseStringDescriptions(),
+			graphql.MaxDepth(10),
+			// List items are resolved in parallel; as many as fit into a
+			// batch can wait on the same loader call.
+			graphql.MaxParallelism(maxBatchSize),
+			graphql.Logger(&panicLogger{ctx: ctx}),
+		),
+	}
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// ServeHTTP executes a query sent as JSON. The viewer is the user_id query
+// parameter, as in the other endpoints.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Query == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{
+			"errors": []map[string]string{{"message": "body must be a JSON object with a query"}},
+		})
+		return
+	}
+	ctx := h.withRequestState(r.Context(), r.URL.Query().Get("user_id"))
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+type stateKey struct{}
+
+// requestState holds the viewer and the loaders of one request.
+type requestState struct {
+	viewerID string
+	settings *loader[string, *models.UserSettings]
+	events   *loader[eventsKey, *eventsResult]
+}
+
+// eventsResult is the outcome of the query a calendar's events were read
+// with, so that an invalid filter fails only the fields using it.
+type eventsResult struct {
+	events []*models.Event
+	err    error
+}
+
+// eventsKey selects the first events of one calendar. Tags and statuses are
+// joined so that the key is comparable.
+type eventsKey struct {
+	calendarID string
+	from       string
+	to         string
+	tags       string
+	statuses   string
+	first      int
+}
+
+func (h *Handler) withRequestState(ctx context.Context, viewerID string) context.Context {
+	state := &requestState{viewerID: viewerID}
+	state.settings = newLoader(func(userIDs []string) (map[string]*models.UserSettings, error) {
+		settings, err := h.srv.GetUsersSettings(userIDs)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]*models.UserSettings, len(settings))
+		for _, s := range settings {
+			values[s.UserID] = s
+		}
+		return values, nil
+	})
+	state.events = newLoader(func(keys []eventsKey) (map[eventsKey]*eventsResult, error) {
+		return h.loadEvents(viewerID, keys), nil
+	})
+	return context.WithValue(ctx, stateKey{}, state)
+}
+
+func stateFrom(ctx context.Context) *requestState {
+	return ctx.Value(stateKey{}).(*requestState)
+}
+
+// loadEvents reads the events of every requested calendar with one query per
+// filter and page, instead of one per calendar. A calendar that has its first
+// events is left out of the next pages, so at most a page more than the
+// requested events is read.
+func (h *Handler) loadEvents(viewerID string, keys []eventsKey) map[eventsKey]*eventsResult {
+	type filter struct {
+		from, to, tags, statuses string
+		first                    int
+	}
+	groups := make(map[filter][]string)
+	for _, key := range keys {
+		f := filter{key.from, key.to, key.tags, key.statuses, key.first}
+		groups[f] = append(groups[f], key.calendarID)
+	}
+	values := make(map[eventsKey]*eventsResult, len(keys))
+	for f, calendarIDs := range groups {
+		for start := 0; start < len(calendarIDs); start += maxCalendarsPerQuery {
+			end := min(start+maxCalendarsPerQuery, len(calendarIDs))
+			chunk := make(map[eventsKey]*eventsResult, end-start)
+			for _, calendarID := range calendarIDs[start:end] {
+				chunk[eventsKey{calendarID, f.from, f.to, f.tags, f.statuses, f.first}] = &eventsResult{}
+			}
+			params := &models.EventQueryParams{
+				ViewerID:    viewerID,
+				CalendarIDs: calendarIDs[start:end],
+				From:        f.from,
+				To:          f.to,
+				Tags:        splitKey(f.tags),
+				Statuses:    splitKey(f.statuses),
+				Limit:       eventsPageSize,
+			}
+			for {
+				page, err := h.srv.QueryEvents(params)
+				if err != nil {
+					for _, result := range chunk {
+						result.events, result.err = nil, err
+					}
+					break
+				}
+				for _, event := range page.Events {
+					result, ok := chunk[eventsKey{event.UserID, f.from, f.to, f.tags, f.statuses, f.first}]
+					if ok && len(result.events) < f.first {
+						result.events = append(result.events, event)
+					}
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				// The cursor is a position in the date order, so it holds
+				// for fewer calendars as well.
+				params.CalendarIDs = nil
+				for key, result := range chunk {
+					if len(result.events) < f.first {
+						params.CalendarIDs = append(params.CalendarIDs, key.calendarID)
+					}
+				}
+				if len(params.CalendarIDs) == 0 {
+					break
+				}
+				params.Cursor = page.NextCursor
+			}
+			maps.Copy(values, chunk)
+		}
+	}
+	return values
+}
+
+func joinKey(values *[]string) string {
+	if values == nil {
+		return ""
+	}
+	return strings.Join(*values, "\x00")
+}
+
+func splitKey(key string) []string {
+	if key == "" {
+		return nil
+	}
+	return strings.Split(key, "\x00")
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	batchWait    = 2 * time.Millisecond
+	maxBatchSize = 100
+)
+
+// loader collects the keys requested by resolvers running in parallel and
+// fetches them with one call. A batch is fetched when it's full or batchWait
+// after its first key. Every key is fetched at most once per request.
+type loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+	mu    sync.Mutex
+	batch *batch[K, V]
+	cache map[K]*batch[K, V]
+}
+
+type batch[K comparable, V any] struct {
+	keys    []K
+	started bool
+	done    chan struct{}
+	values  map[K]V
+	err     error
+}
+
+func newLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch: fetch,
+		cache: make(map[K]*batch[K, V]),
+	}
+}
+
+// Load returns the value of key, or the zero value if fetch didn't return it.
+func (l *loader[K, V]) Load(key K) (V, error) {
+	l.mu.Lock()
+	b, ok := l.cache[key]
+	if !ok {
+		if l.batch == nil {
+			l.batch = &batch[K, V]{done: make(chan struct{})}
+			pending := l.batch
+			time.AfterFunc(batchWait, func() { l.dispatch(pending) })
+		}
+		b = l.batch
+		b.keys = append(b.keys, key)
+		l.cache[key] = b
+		if len(b.keys) >= maxBatchSize {
+			l.batch = nil
+			go l.dispatch(b)
+		}
+	}
+	l.mu.Unlock()
+	<-b.done
+	return b.values[key], b.err
+}
+
+func (l *loader[K, V]) dispatch(b *batch[K, V]) {
+	l.mu.Lock()
+	if b.started {
+		l.mu.Unlock()
+		return
+	}
+	b.started = true
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+	b.values, b.err = l.fetch(b.keys)
+	close(b.done)
+}
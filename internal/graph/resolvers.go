@@ -0,0 +1,294 @@
+package graph
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/service"
+	"context"
+	"encoding/json"
+	errors1 "errors"
+	"github.com/graph-gophers/graphql-go"
+)
+
+type resolver struct {
+	ctx context.Context
+	srv service.CalendarServiceInterface
+}
+
+func (r *resolver) Viewer(ctx context.Context) (*userResolver, error) {
+	viewerID := stateFrom(ctx).viewerID
+	if viewerID == "" {
+		return nil, r.resolverError(&errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		})
+	}
+	return &userResolver{root: r, id: viewerID}, nil
+}
+
+func (r *resolver) User(args struct{ ID graphql.ID }) *userResolver {
+	return &userResolver{root: r, id: string(args.ID)}
+}
+
+func (r *resolver) Calendars(args struct{ IDs []graphql.ID }) ([]*calendarResolver, error) {
+	if len(args.IDs) > maxCalendars {
+		return nil, r.resolverError(&errors.ValidationError{
+			Field:   "ids",
+			Message: "can't contain more than 50 calendars",
+		})
+	}
+	calendars := make([]*calendarResolver, len(args.IDs))
+	for i, id := range args.IDs {
+		calendars[i] = &calendarResolver{root: r, id: string(id)}
+	}
+	return calendars, nil
+}
+
+// Event returns null for events the viewer may not see, like a missing one.
+func (r *resolver) Event(ctx context.Context, args struct{ ID graphql.ID }) (*eventResolver, error) {
+	event, err := r.srv.GetEvent(string(args.ID), stateFrom(ctx).viewerID)
+	var notFoundErr *errors.NotFoundError
+	if errors1.As(err, &notFoundErr) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.resolverError(err)
+	}
+	return &eventResolver{root: r, event: event}, nil
+}
+
+type createEventInput struct {
+	Calendar     *graphql.ID
+	Date         string
+	StartTime    *string
+	EndTime      *string
+	Title        string
+	Description  *string
+	Location     *string
+	URL          *string
+	Color        *string
+	Status       *string
+	Visibility   *string
+	Transparency *string
+	Tags         *[]string
+	Attendees    *[]string
+}
+
+func (r *resolver) CreateEvent(ctx context.Context, args struct{ Input createEventInput }) (*eventResolver, error) {
+	input := args.Input
+	event := &models.Event{
+		UserID:       stateFrom(ctx).viewerID,
+		Date:         input.Date,
+		StartTime:    value(input.StartTime),
+		EndTime:      value(input.EndTime),
+		Event:        input.Title,
+		Description:  value(input.Description),
+		Location:     value(input.Location),
+		URL:          value(input.URL),
+		Color:        value(input.Color),
+		Status:       value(input.Status),
+		Visibility:   value(input.Visibility),
+		Transparency: value(input.Transparency),
+	}
+	if input.Calendar != nil {
+		event.UserID = string(*input.Calendar)
+	}
+	if input.Tags != nil {
+		event.Tags = *input.Tags
+	}
+	if input.Attendees != nil {
+		event.Attendees = *input.Attendees
+	}
+	if _, err := service.WithActor(r.srv, stateFrom(ctx).viewerID).CreateEvent(event); err != nil {
+		return nil, r.resolverError(err)
+	}
+	return &eventResolver{root: r, event: event}, nil
+}
+
+// updateEventInput tells a field set to null, which clears it, from a
+// missing one, which is kept. Lists are replaced when present.
+type updateEventInput struct {
+	Date         graphql.NullString
+	StartTime    graphql.NullString
+	EndTime      graphql.NullString
+	Title        graphql.NullString
+	Description  graphql.NullString
+	Location     graphql.NullString
+	URL          graphql.NullString
+	Color        graphql.NullString
+	Status       graphql.NullString
+	Visibility   graphql.NullString
+	Transparency graphql.NullString
+	Tags         *[]string
+	Attendees    *[]string
+}
+
+// UpdateEvent turns the input into a JSON merge patch, so that fields the
+// schema doesn't expose, like reminders, are kept.
+func (r *resolver) UpdateEvent(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version int32
+	Input   updateEventInput
+}) (*eventResolver, error) {
+	input := args.Input
+	patch := make(map[string]any)
+	for name, field := range map[string]graphql.NullString{
+		"date":         input.Date,
+		"start_time":   input.StartTime,
+		"end_time":     input.EndTime,
+		"event":        input.Title,
+		"description":  input.Description,
+		"location":     input.Location,
+		"url":          input.URL,
+		"color":        input.Color,
+		"status":       input.Status,
+		"visibility":   input.Visibility,
+		"transparency": input.Transparency,
+	} {
+		if field.Set {
+			patch[name] = field.Value
+		}
+	}
+	if input.Tags != nil {
+		patch["tags"] = *input.Tags
+	}
+	if input.Attendees != nil {
+		patch["attendees"] = *input.Attendees
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, r.resolverError(err)
+	}
+	event, err := service.WithActor(r.srv, stateFrom(ctx).viewerID).PatchEvent(string(args.ID), data, int64(args.Version))
+	if err != nil {
+		return nil, r.resolverError(err)
+	}
+	return &eventResolver{root: r, event: event}, nil
+}
+
+func (r *resolver) DeleteEvent(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version int32
+}) (bool, error) {
+	if err := service.WithActor(r.srv, stateFrom(ctx).viewerID).DeleteEvent(string(args.ID), int64(args.Version)); err != nil {
+		return false, r.resolverError(err)
+	}
+	return true, nil
+}
+
+type userResolver struct {
+	root *resolver
+	id   string
+}
+
+func (u *userResolver) ID() graphql.ID {
+	return graphql.ID(u.id)
+}
+
+func (u *userResolver) WeekStart(ctx context.Context) (string, error) {
+	settings, err := stateFrom(ctx).settings.Load(u.id)
+	if err != nil {
+		return "", u.root.resolverError(err)
+	}
+	return settings.WeekStart, nil
+}
+
+func (u *userResolver) Calendar() *calendarResolver {
+	return &calendarResolver{root: u.root, id: u.id}
+}
+
+type calendarResolver struct {
+	root *resolver
+	id   string
+}
+
+func (c *calendarResolver) ID() graphql.ID {
+	return graphql.ID(c.id)
+}
+
+func (c *calendarResolver) Owner() *userResolver {
+	return &userResolver{root: c.root, id: c.id}
+}
+
+func (c *calendarResolver) Events(ctx context.Context, args struct {
+	From     string
+	To       string
+	Tags     *[]string
+	Statuses *[]string
+	First    int32
+}) ([]*eventResolver, error) {
+	if args.First <= 0 || args.First > maxEventsPerCalendar {
+		return nil, c.root.resolverError(&errors.ValidationError{
+			Field:   "first",
+			Message: "must be a number between 1 and 500",
+		})
+	}
+	result, err := stateFrom(ctx).events.Load(eventsKey{
+		calendarID: c.id,
+		from:       args.From,
+		to:         args.To,
+		tags:       joinKey(args.Tags),
+		statuses:   joinKey(args.Statuses),
+		first:      int(args.First),
+	})
+	if err == nil {
+		err = result.err
+	}
+	if err != nil {
+		return nil, c.root.resolverError(err)
+	}
+	events := make([]*eventResolver, len(result.events))
+	for i, event := range result.events {
+		events[i] = &eventResolver{root: c.root, event: event}
+	}
+	return events, nil
+}
+
+type eventResolver struct {
+	root  *resolver
+	event *models.Event
+}
+
+func (e *eventResolver) ID() graphql.ID {
+	return graphql.ID(e.event.EventID)
+}
+
+func (e *eventResolver) Calendar() *calendarResolver {
+	return &calendarResolver{root: e.root, id: e.event.UserID}
+}
+
+func (e *eventResolver) Date() string         { return e.event.Date }
+func (e *eventResolver) StartTime() *string   { return optional(e.event.StartTime) }
+func (e *eventResolver) EndTime() *string     { return optional(e.event.EndTime) }
+func (e *eventResolver) Title() string        { return e.event.Event }
+func (e *eventResolver) Description() *string { return optional(e.event.Description) }
+func (e *eventResolver) Location() *string    { return optional(e.event.Location) }
+func (e *eventResolver) URL() *string         { return optional(e.event.URL) }
+func (e *eventResolver) Color() *string       { return optional(e.event.Color) }
+func (e *eventResolver) Status() string       { return e.event.Status }
+func (e *eventResolver) Visibility() string   { return e.event.Visibility }
+func (e *eventResolver) Transparency() string { return e.event.Transparency }
+func (e *eventResolver) Version() int32       { return int32(e.event.Version) }
+func (e *eventResolver) Tags() []string       { return nonNil(e.event.Tags) }
+func (e *eventResolver) Attendees() []string  { return nonNil(e.event.Attendees) }
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
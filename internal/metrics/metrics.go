@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"time"
+)
+
+const namespace = "calendar"
+
+var (
+	// HTTPRequests counts the served requests by route and status. Requests
+	// matching no route are counted with the route "unmatched".
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests served, by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Time spent serving HTTP requests, by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	RepositoryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "repository",
+		Name:      "call_duration_seconds",
+		Help:      "Time spent in calendar repository calls, by method and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "outcome"})
+
+	// EventChanges counts the stored changes of events by the actions of the
+	// event history: created, updated, deleted, restored and reverted.
+	EventChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "changes_total",
+		Help:      "Stored changes of events, by action.",
+	}, []string{"action"})
+)
+
+// ObserveRepositoryCall records the duration of a repository call that
+// started at start and returned err.
+func ObserveRepositoryCall(method string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	RepositoryDuration.WithLabelValues(method, outcome).Observe(time.Since(start).Seconds())
+}
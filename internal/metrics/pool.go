@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector exports the statistics of a database pool, read on every
+// scrape.
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquires             *prometheus.Desc
+	acquireSeconds       *prometheus.Desc
+	canceledAcquires     *prometheus.Desc
+	emptyAcquires        *prometheus.Desc
+	emptyAcquireSeconds  *prometheus.Desc
+	newConns             *prometheus.Desc
+	maxLifetimeDestroyed *prometheus.Desc
+	maxIdleDestroyed     *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &PoolCollector{
+		pool:                 pool,
+		acquiredConns:        desc("acquired_connections", "Connections currently in use."),
+		idleConns:            desc("idle_connections", "Connections currently idle."),
+		constructingConns:    desc("constructing_connections", "Connections being opened."),
+		totalConns:           desc("total_connections", "Connections currently open or being opened."),
+		maxConns:             desc("max_connections", "Maximum size of the pool."),
+		acquires:             desc("acquires_total", "Successful connection acquires."),
+		acquireSeconds:       desc("acquire_seconds_total", "Time spent in successful connection acquires."),
+		canceledAcquires:     desc("canceled_acquires_total", "Acquires canceled by their context."),
+		emptyAcquires:        desc("empty_acquires_total", "Successful acquires that had to wait for a connection."),
+		emptyAcquireSeconds:  desc("empty_acquire_wait_seconds_total", "Time spent waiting for a connection by empty acquires."),
+		newConns:             desc("new_connections_total", "Connections opened."),
+		maxLifetimeDestroyed: desc("max_lifetime_destroyed_total", "Connections closed for exceeding their maximum lifetime."),
+		maxIdleDestroyed:     desc("max_idle_destroyed_total", "Connections closed for exceeding their maximum idle time."),
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	counter(c.acquires, float64(stat.AcquireCount()))
+	counter(c.acquireSeconds, stat.AcquireDuration().Seconds())
+	counter(c.canceledAcquires, float64(stat.CanceledAcquireCount()))
+	counter(c.emptyAcquires, float64(stat.EmptyAcquireCount()))
+	counter(c.emptyAcquireSeconds, stat.EmptyAcquireWaitTime().Seconds())
+	counter(c.newConns, float64(stat.NewConnsCount()))
+	counter(c.maxLifetimeDestroyed, float64(stat.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleDestroyed, float64(stat.MaxIdleDestroyCount()))
+}
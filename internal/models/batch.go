@@ -0,0 +1,35 @@
+package models
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+const (
+	// BatchAtomic applies all operations in one transaction or none of them.
+	BatchAtomic = "atomic"
+	// BatchBestEffort applies every operation on its own.
+	BatchBestEffort = "best_effort"
+)
+
+// BatchOperation creates, updates or deletes one event. Creates and updates
+// carry the event, deletes the event ID and the expected version.
+type BatchOperation struct {
+	Op      string `json:"op"`
+	Event   *Event `json:"event,omitempty"`
+	EventID string `json:"event_id,omitempty"`
+	Version int64  `json:"version,omitempty"`
+}
+
+type Batch struct {
+	Mode       string            `json:"mode"`
+	Operations []*BatchOperation `json:"operations"`
+}
+
+// BatchResult is the outcome of the operation at the same index: the stored
+// event, nothing for a delete, or the error the operation failed with.
+type BatchResult struct {
+	Event *Event
+	Err   error
+}
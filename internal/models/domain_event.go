@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+	EventCreated = "event.created"
+	EventUpdated = "event.updated"
+	EventDeleted = "event.deleted"
+)
+
+// DomainEvent describes a committed change of an event. Before is empty for
+// created events and After is empty for deleted ones. Seq numbers the changes
+// of a calendar in commit order, unlike ID, and is what clients resume from.
+type DomainEvent struct {
+	ID         int64     `json:"id"`
+	Seq        int64     `json:"seq"`
+	Type       string    `json:"type"`
+	EventID    string    `json:"event_id"`
+	UserID     string    `json:"user_id"`
+	Before     *Event    `json:"before,omitempty"`
+	After      *Event    `json:"after,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// ViewFor returns the change as the viewer is allowed to see it, or nil if the
+// event is hidden from the viewer both before and after the change. An event
+// that becomes hidden is reported as deleted and one that becomes visible as
+// created.
+func (d *DomainEvent) ViewFor(viewerID string) *DomainEvent {
+	if d.UserID == viewerID {
+		return d
+	}
+	view := *d
+	view.Before = d.Before.ViewFor(viewerID)
+	view.After = d.After.ViewFor(viewerID)
+	switch {
+	case view.Before == nil && view.After == nil:
+		return nil
+	case d.Type == EventUpdated && view.After == nil:
+		view.Type = EventDeleted
+	case d.Type == EventUpdated && view.Before == nil:
+		view.Type = EventCreated
+	}
+	return &view
+}
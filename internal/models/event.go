@@ -1,8 +1,77 @@
 package models
 
+import "time"
+
+const (
+	StatusConfirmed = "confirmed"
+	StatusTentative = "tentative"
+	StatusCancelled = "cancelled"
+)
+
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+	VisibilityBusy    = "busy"
+)
+
+const (
+	TransparencyOpaque      = "opaque"
+	TransparencyTransparent = "transparent"
+)
+
+// BusyTitle replaces the title of busy-only events shown to other users.
+const BusyTitle = "Busy"
+
 type Event struct {
-	UserID  string `json:"user_id"`
-	EventID string `json:"event_id"`
-	Date    string `json:"date"`
-	Event   string `json:"event"`
+	UserID       string      `json:"user_id"`
+	EventID      string      `json:"event_id"`
+	Date         string      `json:"date"`
+	StartTime    string      `json:"start_time,omitempty"`
+	EndTime      string      `json:"end_time,omitempty"`
+	Event        string      `json:"event"`
+	Description  string      `json:"description,omitempty"`
+	Location     string      `json:"location,omitempty"`
+	URL          string      `json:"url,omitempty"`
+	Color        string      `json:"color,omitempty"`
+	Status       string      `json:"status,omitempty"`
+	Visibility   string      `json:"visibility,omitempty"`
+	Transparency string      `json:"transparency,omitempty"`
+	Tags         []string    `json:"tags,omitempty"`
+	Attendees    []string    `json:"attendees,omitempty"`
+	Reminders    []*Reminder `json:"reminders,omitempty"`
+	Version      int64       `json:"version,omitempty"`
+	DeletedAt    *time.Time  `json:"deleted_at,omitempty"`
+}
+
+// ViewFor returns the event as the viewer is allowed to see it. The owner sees
+// everything, other users don't see private events at all and see busy-only
+// events as a time slot without details. A transparent busy-only event doesn't
+// block its slot, so other users don't see it either.
+func (e *Event) ViewFor(viewerID string) *Event {
+	if e == nil || e.UserID == viewerID {
+		return e
+	}
+	switch e.Visibility {
+	case VisibilityPrivate:
+		return nil
+	case VisibilityBusy:
+		if e.Transparency == TransparencyTransparent {
+			return nil
+		}
+		return &Event{
+			UserID:       e.UserID,
+			EventID:      e.EventID,
+			Date:         e.Date,
+			StartTime:    e.StartTime,
+			EndTime:      e.EndTime,
+			Event:        BusyTitle,
+			Status:       e.Status,
+			Visibility:   e.Visibility,
+			Transparency: e.Transparency,
+			Version:      e.Version,
+		}
+	}
+	view := *e
+	view.Reminders = nil
+	return &view
 }
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+const (
+	HistoryCreated  = "created"
+	HistoryUpdated  = "updated"
+	HistoryDeleted  = "deleted"
+	HistoryRestored = "restored"
+	HistoryReverted = "reverted"
+)
+
+// HistoryEntry is one revision of an event. Entries are never changed or
+// removed, not even when the event is purged from the trash. Revisions of an
+// event are numbered from 1 in the order of the changes.
+type HistoryEntry struct {
+	ID         int64                  `json:"id"`
+	EventID    string                 `json:"event_id"`
+	UserID     string                 `json:"user_id"`
+	Revision   int                    `json:"revision"`
+	Version    int64                  `json:"version"`
+	Action     string                 `json:"action"`
+	Actor      string                 `json:"actor"`
+	ChangedAt  time.Time              `json:"changed_at"`
+	Changes    map[string]FieldChange `json:"changes"`
+	RevertedTo int                    `json:"reverted_to,omitempty"`
+	Snapshot   *Event                 `json:"snapshot"`
+}
+
+// FieldChange is the value of an event field before and after a change, null
+// when the event didn't have the field.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// AuditQuery selects history entries of all events, the newest first. Zero
+// fields don't restrict the result.
+type AuditQuery struct {
+	Actor    string
+	UserID   string
+	From     time.Time
+	To       time.Time
+	BeforeID int64
+	Limit    int
+}
+
+// AuditParams holds the raw query parameters of the audit endpoint.
+type AuditParams struct {
+	Actor  string
+	UserID string
+	From   string
+	To     string
+	Cursor string
+	Limit  string
+}
+
+type AuditPage struct {
+	Entries    []*HistoryEntry `json:"entries"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// DiffEvents returns the fields that differ between before and after, either
+// of which may be nil. The version and the IDs the server assigns to
+// reminders aren't compared.
+func DiffEvents(before, after *Event) map[string]FieldChange {
+	from, to := diffFields(before), diffFields(after)
+	changes := make(map[string]FieldChange)
+	for _, fields := range []map[string]any{from, to} {
+		for field := range fields {
+			if !reflect.DeepEqual(from[field], to[field]) {
+				changes[field] = FieldChange{From: from[field], To: to[field]}
+			}
+		}
+	}
+	return changes
+}
+
+// diffFields returns the JSON fields of the event as they are compared by
+// DiffEvents.
+func diffFields(event *Event) map[string]any {
+	if event == nil {
+		return nil
+	}
+	view := *event
+	view.Version = 0
+	view.DeletedAt = nil
+	data, _ := json.Marshal(&view)
+	var fields map[string]any
+	_ = json.Unmarshal(data, &fields)
+	if reminders, ok := fields["reminders"].([]any); ok {
+		for _, reminder := range reminders {
+			delete(reminder.(map[string]any), "reminder_id")
+			delete(reminder.(map[string]any), "event_id")
+		}
+	}
+	return fields
+}
@@ -1,5 +1,6 @@
 package models
 
 type ID struct {
-	ID string `json:"id"`
+	ID      string `json:"id"`
+	Version int64  `json:"version,omitempty"`
 }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is the response stored for an Idempotency-Key, together
+// with the fingerprint of the request that produced it. Status is zero while
+// that request is still running. Keys are unique per Scope, the caller that
+// sent them.
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	Status      int
+	Header      map[string]string
+	Body        []byte
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	SortAsc  = "date"
+	SortDesc = "-date"
+)
+
+// EventQuery selects events of one or more calendars. A calendar is
+// identified by the ID of the user who owns it. Private events of calendars
+// not owned by the viewer are left out.
+type EventQuery struct {
+	ViewerID    string
+	CalendarIDs []string
+	From        time.Time
+	To          time.Time
+	Text        string
+	Statuses    []string
+	Tags        []string
+	Desc        bool
+	After       *EventCursor
+	Limit       int
+}
+
+// EventCursor is the sort key of the last event of a page.
+type EventCursor struct {
+	Date      string `json:"d"`
+	StartTime string `json:"t"`
+	EventID   string `json:"i"`
+}
+
+// EventQueryParams holds the raw query parameters of the search endpoint.
+type EventQueryParams struct {
+	ViewerID    string
+	CalendarIDs []string
+	From        string
+	To          string
+	Text        string
+	Statuses    []string
+	Tags        []string
+	Sort        string
+	Cursor      string
+	Limit       string
+}
+
+type EventPage struct {
+	Events     []*Event `json:"events"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+const (
+	ChannelLog     = "log"
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+)
+
+type Reminder struct {
+	ReminderID    string `json:"reminder_id"`
+	EventID       string `json:"event_id"`
+	OffsetMinutes int    `json:"offset_minutes"`
+	Channel       string `json:"channel"`
+	Target        string `json:"target,omitempty"`
+}
+
+type ReminderJob struct {
+	JobID    string
+	RunAt    time.Time
+	Attempts int
+	Reminder Reminder
+	Event    Event
+}
+
+// Notification is delivered at least once: a delivery whose job could not be
+// completed is sent again with the same DeliveryID, which receivers can use
+// to drop the duplicate.
+type Notification struct {
+	DeliveryID string    `json:"delivery_id"`
+	ReminderID string    `json:"reminder_id"`
+	EventID    string    `json:"event_id"`
+	UserID     string    `json:"user_id"`
+	Event      string    `json:"event"`
+	Date       string    `json:"date"`
+	StartTime  string    `json:"start_time,omitempty"`
+	Channel    string    `json:"channel"`
+	Target     string    `json:"target,omitempty"`
+	FireAt     time.Time `json:"fire_at"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+const (
+	GroupByCalendar = "calendar"
+	GroupByTag      = "tag"
+	GroupByAttendee = "attendee"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// ReportQuery selects the events aggregated by a time report. Weeks start on
+// WeekStart.
+type ReportQuery struct {
+	ViewerID    string
+	CalendarIDs []string
+	From        time.Time
+	To          time.Time
+	Bucket      string
+	GroupBy     string
+	WeekStart   time.Weekday
+}
+
+// ReportParams holds the raw query parameters of the report endpoint.
+type ReportParams struct {
+	ViewerID    string
+	CalendarIDs []string
+	From        string
+	To          string
+	Bucket      string
+	GroupBy     string
+}
+
+// ReportRow is the time spent in the events of one group during one bucket.
+// Bucket is the first day of the bucket. The key is empty for events without
+// a tag or without attendees.
+type ReportRow struct {
+	Bucket string  `json:"bucket"`
+	Key    string  `json:"key"`
+	Hours  float64 `json:"hours"`
+	Events int     `json:"events"`
+}
+
+type Report struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Bucket  string       `json:"bucket"`
+	GroupBy string       `json:"group_by"`
+	Rows    []*ReportRow `json:"rows"`
+}
@@ -0,0 +1,13 @@
+package models
+
+const (
+	LanguageRussian = "russian"
+	LanguageEnglish = "english"
+)
+
+type SearchResult struct {
+	Event     *Event  `json:"event"`
+	Rank      float32 `json:"rank"`
+	Highlight string  `json:"highlight"`
+	Snippet   string  `json:"snippet,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+const (
+	WeekStartMonday = "monday"
+	WeekStartSunday = "sunday"
+)
+
+const (
+	WindowCalendar = "calendar"
+	WindowRolling  = "rolling"
+)
+
+type UserSettings struct {
+	UserID    string `json:"user_id"`
+	WeekStart string `json:"week_start"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type Tombstone struct {
+	EventID   string    `json:"event_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// SyncChange is a single row of the delta feed: either the current state of an
+// event or a tombstone when Event is nil.
+type SyncChange struct {
+	Seq        int64
+	CreatedSeq int64
+	Event      *Event
+	Tombstone  *Tombstone
+}
+
+type SyncResult struct {
+	Created   []*Event     `json:"created"`
+	Updated   []*Event     `json:"updated"`
+	Deleted   []*Tombstone `json:"deleted"`
+	SyncToken string       `json:"sync_token"`
+	HasMore   bool         `json:"has_more"`
+}
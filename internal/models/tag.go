@@ -0,0 +1,18 @@
+package models
+
+// Tag is an entry of the user's tag catalogue. Tag names are unique per user
+// regardless of case.
+type Tag struct {
+	TagID      string `json:"tag_id"`
+	UserID     string `json:"user_id"`
+	Name       string `json:"name"`
+	EventCount int    `json:"event_count"`
+}
+
+// TagMerge moves the events of the source tag to the target tag and removes
+// the source tag.
+type TagMerge struct {
+	UserID      string `json:"user_id"`
+	SourceTagID string `json:"source_tag_id"`
+	TargetTagID string `json:"target_tag_id"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeliveryPending    = "pending"
+	DeliveryProcessing = "processing"
+	DeliveryDelivered  = "delivered"
+	DeliveryDead       = "dead"
+)
+
+type WebhookSubscription struct {
+	SubscriptionID string    `json:"subscription_id"`
+	UserID         string    `json:"user_id"`
+	URL            string    `json:"url"`
+	EventTypes     []string  `json:"event_types"`
+	Secret         string    `json:"secret,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	DeliveryID     string          `json:"delivery_id"`
+	SubscriptionID string          `json:"subscription_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	URL            string          `json:"-"`
+	Secret         string          `json:"-"`
+}
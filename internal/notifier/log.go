@@ -0,0 +1,25 @@
+package notifier
+
+import (
+	"Calendar/internal/models"
+	"Calendar/pkg/logger"
+	"context"
+	"go.uber.org/zap"
+)
+
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (l *LogNotifier) Notify(ctx context.Context, n *models.Notification) error {
+	logger.GetLoggerFromCtx(ctx).Info("Reminder:",
+		zap.String("user_id", n.UserID),
+		zap.String("event_id", n.EventID),
+		zap.String("event", n.Event),
+		zap.String("date", n.Date),
+		zap.String("start_time", n.StartTime),
+	)
+	return nil
+}
@@ -0,0 +1,30 @@
+package notifier
+
+import (
+	"Calendar/internal/models"
+	"context"
+	"fmt"
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, n *models.Notification) error
+}
+
+// Dispatcher routes a notification to the notifier registered for its channel.
+type Dispatcher struct {
+	notifiers map[string]Notifier
+}
+
+func NewDispatcher(notifiers map[string]Notifier) *Dispatcher {
+	return &Dispatcher{
+		notifiers: notifiers,
+	}
+}
+
+func (d *Dispatcher) Notify(ctx context.Context, n *models.Notification) error {
+	notifier, ok := d.notifiers[n.Channel]
+	if !ok {
+		return fmt.Errorf("no notifier for channel: %s", n.Channel)
+	}
+	return notifier.Notify(ctx, n)
+}
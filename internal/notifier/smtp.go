@@ -0,0 +1,114 @@
+package notifier
+
+import (
+	"Calendar/internal/models"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string `yaml:"host" env:"SMTP_HOST" env-default:"localhost"`
+	Port     string `yaml:"port" env:"SMTP_PORT" env-default:"25"`
+	User     string `yaml:"user" env:"SMTP_USER"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+	From     string `yaml:"from" env:"SMTP_FROM" env-default:"calendar@localhost"`
+	// Timeout bounds the whole exchange with the server.
+	Timeout time.Duration `yaml:"timeout" env:"SMTP_TIMEOUT" env-default:"30s"`
+}
+
+var headerReplacer = strings.NewReplacer("\r", " ", "\n", " ")
+
+type SMTPNotifier struct {
+	cfg SMTPConfig
+}
+
+func NewSMTPNotifier(cfg SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{
+		cfg: cfg,
+	}
+}
+
+// Notify sends the reminder by email. The exchange is given up when ctx is
+// done or after the configured timeout, so a hung server can't hold up the
+// reminder worker.
+func (s *SMTPNotifier) Notify(ctx context.Context, n *models.Notification) error {
+	if err := s.send(ctx, n.Target, s.message(n)); err != nil {
+		return fmt.Errorf("error sending email: %w", err)
+	}
+	return nil
+}
+
+// send does what smtp.SendMail does over a connection bound to ctx.
+func (s *SMTPNotifier) send(ctx context.Context, to string, msg []byte) error {
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.cfg.Host, s.cfg.Port))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// A cancelled ctx fails the pending read or write at once.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.User != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.User, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (s *SMTPNotifier) message(n *models.Notification) []byte {
+	when := n.Date
+	if n.StartTime != "" {
+		when += " " + n.StartTime
+	}
+	var b strings.Builder
+	b.WriteString("From: " + s.cfg.From + "\r\n")
+	b.WriteString("To: " + n.Target + "\r\n")
+	b.WriteString("Message-ID: <" + n.DeliveryID + "@" + s.cfg.Host + ">\r\n")
+	b.WriteString("Subject: Reminder: " + headerReplacer.Replace(n.Event) + "\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(n.Event + " starts at " + when + "\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,43 @@
+package notifier
+
+import (
+	"Calendar/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+func NewWebhookNotifier(timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n *models.Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("error encoding notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.Target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Calendar-Delivery", n.DeliveryID)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status: %d", resp.StatusCode)
+	}
+	return nil
+}
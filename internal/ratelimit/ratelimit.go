@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Config sets how many requests a client may make per window. Clients with
+// one of the APIKeys are limited per key; any other request is limited per
+// IP address and, if it names a user, per user as well. A limit of 0 turns
+// limiting off for that kind of client.
+type Config struct {
+	Enabled     bool          `yaml:"enabled" env:"ENABLED" env-default:"true"`
+	Window      time.Duration `yaml:"window" env:"WINDOW" env-default:"1m"`
+	APIKeys     []string      `yaml:"api_keys" env:"API_KEYS"`
+	APIKeyLimit int           `yaml:"api_key_limit" env:"API_KEY_LIMIT" env-default:"600"`
+	UserLimit   int           `yaml:"user_limit" env:"USER_LIMIT" env-default:"300"`
+	IPLimit     int           `yaml:"ip_limit" env:"IP_LIMIT" env-default:"120"`
+}
+
+// Result tells whether a request may go ahead and how much of its client's
+// limit is left.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, if this one
+	// wasn't.
+	RetryAfter time.Duration
+}
+
+// Limiter keeps a token bucket per client. A bucket holds up to limit tokens
+// and refills at limit tokens per window, so a client may send a burst of
+// limit requests and then limit requests per window; every request takes
+// one token.
+type Limiter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewLimiter(window time.Duration) *Limiter {
+	return &Limiter{
+		window:  window,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from the bucket of key, which holds up to limit
+// tokens, if there is one.
+func (l *Limiter) Allow(key string, limit int, now time.Time) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	rate := float64(limit) / l.window.Seconds()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	result := Result{Limit: limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((float64(limit) - b.tokens) / rate)
+	return result
+}
+
+// sweep forgets the buckets that have been refilled completely, at most
+// once per window; a missing bucket starts full.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.window {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"Calendar/internal/models"
+	"Calendar/pkg/logger"
+	"fmt"
+	"go.uber.org/zap"
+)
+
+// BatchError reports the operation an atomic batch stopped at. Nothing of the
+// batch was stored.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch operation %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyBatch runs the operations in order in one transaction. Operations see
+// the changes of the ones before them, and the first failing one rolls back
+// the whole batch.
+func (r *CalendarRepository) ApplyBatch(operations []*models.BatchOperation) error {
+	tx, err := r.db.Begin(r.ctx)
+	if err != nil {
+		return fmt.Errorf("error applying batch: %w", err)
+	}
+	defer tx.Rollback(r.ctx)
+	for i, op := range operations {
+		switch op.Op {
+		case models.BatchCreate:
+			err = r.createEvent(tx, op.Event)
+		case models.BatchUpdate:
+			err = r.updateEvent(tx, op.Event)
+		case models.BatchDelete:
+			err = r.deleteEvent(tx, op.EventID, op.Version)
+		default:
+			err = fmt.Errorf("unknown batch operation %q", op.Op)
+		}
+		if err != nil {
+			return &BatchError{Index: i, Err: err}
+		}
+	}
+	if err := tx.Commit(r.ctx); err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error applying batch", zap.Error(err))
+		return fmt.Errorf("error applying batch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"Calendar/internal/models"
+	"Calendar/pkg/logger"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+const historyColumns = "id, event_id, user_id, revision, version, action, actor, changed_at, changes, " +
+	"COALESCE(reverted_to, 0), snapshot"
+
+// WithActor returns a repository that records actorID as the author of the
+// changes it makes. Without an actor, changes are attributed to the owner of
+// the event.
+func (r *CalendarRepository) WithActor(actorID string) CalendarRepositoryInterface {
+	scoped := *r
+	scoped.actor = actorID
+	return &scoped
+}
+
+// WithActor returns repo attributing its changes to actorID, or repo itself
+// if it doesn't record who made a change.
+func WithActor(repo CalendarRepositoryInterface, actorID string) CalendarRepositoryInterface {
+	if scoped, ok := repo.(interface {
+		WithActor(actorID string) CalendarRepositoryInterface
+	}); ok {
+		return scoped.WithActor(actorID)
+	}
+	return repo
+}
+
+// GetHistory returns every revision of the event, the oldest first.
+func (r *CalendarRepository) GetHistory(eventID string) ([]*models.HistoryEntry, error) {
+	entries, err := r.queryHistory(
+		"SELECT "+historyColumns+" FROM event_history WHERE event_id = $1 ORDER BY revision",
+		eventID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting history: %w", err)
+	}
+	return entries, nil
+}
+
+// Audit returns the history entries of all events matching the query, the
+// newest first.
+func (r *CalendarRepository) Audit(query *models.AuditQuery) ([]*models.HistoryEntry, error) {
+	sql := "SELECT " + historyColumns + " FROM event_history WHERE true"
+	var args []any
+	if query.Actor != "" {
+		args = append(args, query.Actor)
+		sql += fmt.Sprintf(" AND actor = $%d", len(args))
+	}
+	if query.UserID != "" {
+		args = append(args, query.UserID)
+		sql += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+	if !query.From.IsZero() {
+		args = append(args, query.From)
+		sql += fmt.Sprintf(" AND changed_at >= $%d", len(args))
+	}
+	if !query.To.IsZero() {
+		args = append(args, query.To)
+		sql += fmt.Sprintf(" AND changed_at < $%d", len(args))
+	}
+	if query.BeforeID > 0 {
+		args = append(args, query.BeforeID)
+		sql += fmt.Sprintf(" AND id < $%d", len(args))
+	}
+	args = append(args, query.Limit)
+	sql += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	entries, err := r.queryHistory(sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// RevertEvent replaces the event like UpdateEvent and records the change as a
+// revert to the given revision.
+func (r *CalendarRepository) RevertEvent(event *models.Event, revision int) error {
+	tx, err := r.db.Begin(r.ctx)
+	if err != nil {
+		return fmt.Errorf("error reverting event: %w", err)
+	}
+	defer tx.Rollback(r.ctx)
+	if err := r.replaceEvent(tx, event, revision); err != nil {
+		return err
+	}
+	if err := tx.Commit(r.ctx); err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error reverting event", zap.Error(err))
+		return fmt.Errorf("error reverting event: %w", err)
+	}
+	return nil
+}
+
+func (r *CalendarRepository) queryHistory(sql string, args ...any) ([]*models.HistoryEntry, error) {
+	var entries []*models.HistoryEntry
+
+	rows, err := r.db.Query(r.ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry models.HistoryEntry
+		err := rows.Scan(&entry.ID, &entry.EventID, &entry.UserID, &entry.Revision, &entry.Version, &entry.Action,
+			&entry.Actor, &entry.ChangedAt, &entry.Changes, &entry.RevertedTo, &entry.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+// insertHistory appends the change to the history of the event in the same
+// transaction as the event row. The snapshot is the event after the change,
+// or before it if it was deleted. Callers hold the lock of the event row,
+// which keeps revisions of the same event from being numbered concurrently.
+func (r *CalendarRepository) insertHistory(tx pgx.Tx, action string, before, after *models.Event, revertedTo int) error {
+	snapshot := after
+	if snapshot == nil {
+		snapshot = before
+	}
+	actor := r.actor
+	if actor == "" && before != nil {
+		actor = before.UserID
+	} else if actor == "" {
+		actor = after.UserID
+	}
+	_, err := tx.Exec(r.ctx,
+		"INSERT INTO event_history (event_id, user_id, revision, version, action, actor, changes, reverted_to, snapshot) "+
+			"SELECT $1, $2, COALESCE(max(revision), 0) + 1, $3, $4, $5, $6, NULLIF($7, 0), $8 "+
+			"FROM event_history WHERE event_id = $1",
+		snapshot.EventID,
+		snapshot.UserID,
+		snapshot.Version,
+		action,
+		actor,
+		models.DiffEvents(before, after),
+		revertedTo,
+		snapshot,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error writing history", zap.Error(err))
+		return fmt.Errorf("error writing history: %w", err)
+	}
+	return nil
+}
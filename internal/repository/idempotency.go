@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"Calendar/internal/models"
+	"Calendar/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+type IdempotencyRepositoryInterface interface {
+	Claim(record *models.IdempotencyRecord, lease time.Duration) (*models.IdempotencyRecord, error)
+	Save(record *models.IdempotencyRecord) error
+	Release(scope string, key string) error
+	DeleteExpired(limit int) (int64, error)
+}
+
+type IdempotencyRepository struct {
+	ctx context.Context
+	db  *pgxpool.Pool
+}
+
+func NewIdempotencyRepository(ctx context.Context, db *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		ctx: ctx,
+		db:  db,
+	}
+}
+
+// Claim stores the key for a new request and returns nil, or returns the
+// record already stored for it. Expired keys and keys whose request has been
+// running for longer than lease, which most likely died with its server, are
+// taken over.
+func (r *IdempotencyRepository) Claim(record *models.IdempotencyRecord, lease time.Duration) (*models.IdempotencyRecord, error) {
+	// The stored record may expire between the insert and the select; the
+	// second round takes it over then.
+	for range 2 {
+		tag, err := r.db.Exec(r.ctx,
+			"INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at) VALUES ($1, $2, $3, $4) "+
+				"ON CONFLICT (scope, key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status = 0, header = NULL, body = NULL, "+
+				"created_at = now(), expires_at = EXCLUDED.expires_at "+
+				"WHERE idempotency_keys.expires_at <= now() "+
+				"OR (idempotency_keys.status = 0 AND idempotency_keys.created_at <= now() - make_interval(secs => $5))",
+			record.Scope,
+			record.Key,
+			record.Fingerprint,
+			record.ExpiresAt,
+			lease.Seconds(),
+		)
+		if err != nil {
+			logger.GetLoggerFromCtx(r.ctx).Error("error claiming idempotency key", zap.Error(err))
+			return nil, fmt.Errorf("error claiming idempotency key: %w", err)
+		}
+		if tag.RowsAffected() == 1 {
+			return nil, nil
+		}
+		stored := &models.IdempotencyRecord{Scope: record.Scope, Key: record.Key}
+		err = r.db.QueryRow(r.ctx,
+			"SELECT fingerprint, status, COALESCE(header, '{}'), COALESCE(body, ''), expires_at FROM idempotency_keys "+
+				"WHERE scope = $1 AND key = $2 AND expires_at > now()",
+			record.Scope,
+			record.Key,
+		).Scan(&stored.Fingerprint, &stored.Status, &stored.Header, &stored.Body, &stored.ExpiresAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			logger.GetLoggerFromCtx(r.ctx).Error("error reading idempotency key", zap.Error(err))
+			return nil, fmt.Errorf("error reading idempotency key: %w", err)
+		}
+		return stored, nil
+	}
+	return nil, fmt.Errorf("error claiming idempotency key %s", record.Key)
+}
+
+// Save stores the response of the request that claimed the key.
+func (r *IdempotencyRepository) Save(record *models.IdempotencyRecord) error {
+	_, err := r.db.Exec(r.ctx,
+		"UPDATE idempotency_keys SET status = $3, header = $4, body = $5 WHERE scope = $1 AND key = $2 AND status = 0",
+		record.Scope,
+		record.Key,
+		record.Status,
+		record.Header,
+		record.Body,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error saving idempotency key", zap.Error(err))
+		return fmt.Errorf("error saving idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release removes a key whose request produced no response worth replaying,
+// so that a retry runs again.
+func (r *IdempotencyRepository) Release(scope string, key string) error {
+	_, err := r.db.Exec(r.ctx, "DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status = 0", scope, key)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error releasing idempotency key", zap.Error(err))
+		return fmt.Errorf("error releasing idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes up to limit expired keys and returns how many it
+// removed.
+func (r *IdempotencyRepository) DeleteExpired(limit int) (int64, error) {
+	tag, err := r.db.Exec(r.ctx,
+		"DELETE FROM idempotency_keys WHERE (scope, key) IN "+
+			"(SELECT scope, key FROM idempotency_keys WHERE expires_at <= now() LIMIT $1)",
+		limit,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error deleting idempotency keys", zap.Error(err))
+		return 0, fmt.Errorf("error deleting idempotency keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
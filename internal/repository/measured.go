@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"Calendar/internal/metrics"
+	"Calendar/internal/models"
+	"time"
+)
+
+// MeasuredRepository records the duration of every call to the wrapped
+// repository and counts the changes of events it stores.
+type MeasuredRepository struct {
+	repo CalendarRepositoryInterface
+}
+
+func NewMeasuredRepository(repo CalendarRepositoryInterface) *MeasuredRepository {
+	return &MeasuredRepository{
+		repo: repo,
+	}
+}
+
+// measure runs call and records its duration under method.
+func measure[T any](method string, call func() (T, error)) (T, error) {
+	start := time.Now()
+	result, err := call()
+	metrics.ObserveRepositoryCall(method, start, err)
+	return result, err
+}
+
+// measureCall runs call and records its duration under method.
+func measureCall(method string, call func() error) error {
+	start := time.Now()
+	err := call()
+	metrics.ObserveRepositoryCall(method, start, err)
+	return err
+}
+
+// measureChange runs call, records its duration under method and counts the
+// change as action if it was stored.
+func measureChange(method string, action string, call func() error) error {
+	err := measureCall(method, call)
+	if err == nil {
+		metrics.EventChanges.WithLabelValues(action).Inc()
+	}
+	return err
+}
+
+// WithActor keeps the measurements on the repository scoped to actorID.
+func (m *MeasuredRepository) WithActor(actorID string) CalendarRepositoryInterface {
+	return NewMeasuredRepository(WithActor(m.repo, actorID))
+}
+
+func (m *MeasuredRepository) CreateEvent(event *models.Event) error {
+	return measureChange("CreateEvent", models.HistoryCreated, func() error { return m.repo.CreateEvent(event) })
+}
+
+func (m *MeasuredRepository) GetEvent(eventID string) (*models.Event, error) {
+	return measure("GetEvent", func() (*models.Event, error) { return m.repo.GetEvent(eventID) })
+}
+
+func (m *MeasuredRepository) GetTrash(userID string, limit int) ([]*models.Event, error) {
+	return measure("GetTrash", func() ([]*models.Event, error) { return m.repo.GetTrash(userID, limit) })
+}
+
+func (m *MeasuredRepository) RestoreEvent(eventID string) (*models.Event, error) {
+	event, err := measure("RestoreEvent", func() (*models.Event, error) { return m.repo.RestoreEvent(eventID) })
+	if err == nil {
+		metrics.EventChanges.WithLabelValues(models.HistoryRestored).Inc()
+	}
+	return event, err
+}
+
+func (m *MeasuredRepository) PurgeTrash(retention time.Duration, limit int) (int64, error) {
+	return measure("PurgeTrash", func() (int64, error) { return m.repo.PurgeTrash(retention, limit) })
+}
+
+func (m *MeasuredRepository) QueryEvents(query *models.EventQuery) ([]*models.Event, error) {
+	return measure("QueryEvents", func() ([]*models.Event, error) { return m.repo.QueryEvents(query) })
+}
+
+func (m *MeasuredRepository) DeleteEvent(eventID string, version int64) error {
+	return measureChange("DeleteEvent", models.HistoryDeleted, func() error { return m.repo.DeleteEvent(eventID, version) })
+}
+
+func (m *MeasuredRepository) UpdateEvent(event *models.Event) error {
+	return measureChange("UpdateEvent", models.HistoryUpdated, func() error { return m.repo.UpdateEvent(event) })
+}
+
+// ApplyBatch counts every operation of a stored batch.
+func (m *MeasuredRepository) ApplyBatch(operations []*models.BatchOperation) error {
+	if err := measureCall("ApplyBatch", func() error { return m.repo.ApplyBatch(operations) }); err != nil {
+		return err
+	}
+	for _, op := range operations {
+		switch op.Op {
+		case models.BatchCreate:
+			metrics.EventChanges.WithLabelValues(models.HistoryCreated).Inc()
+		case models.BatchUpdate:
+			metrics.EventChanges.WithLabelValues(models.HistoryUpdated).Inc()
+		case models.BatchDelete:
+			metrics.EventChanges.WithLabelValues(models.HistoryDeleted).Inc()
+		}
+	}
+	return nil
+}
+
+func (m *MeasuredRepository) GetHistory(eventID string) ([]*models.HistoryEntry, error) {
+	return measure("GetHistory", func() ([]*models.HistoryEntry, error) { return m.repo.GetHistory(eventID) })
+}
+
+func (m *MeasuredRepository) Audit(query *models.AuditQuery) ([]*models.HistoryEntry, error) {
+	return measure("Audit", func() ([]*models.HistoryEntry, error) { return m.repo.Audit(query) })
+}
+
+func (m *MeasuredRepository) RevertEvent(event *models.Event, revision int) error {
+	return measureChange("RevertEvent", models.HistoryReverted, func() error { return m.repo.RevertEvent(event, revision) })
+}
+
+func (m *MeasuredRepository) CountEvents(userID string) (int, error) {
+	return measure("CountEvents", func() (int, error) { return m.repo.CountEvents(userID) })
+}
+
+func (m *MeasuredRepository) GetCalendarsCreatedBy(actorID string) ([]string, error) {
+	return measure("GetCalendarsCreatedBy", func() ([]string, error) { return m.repo.GetCalendarsCreatedBy(actorID) })
+}
+
+func (m *MeasuredRepository) SyncEvents(userID string, afterSeq int64, limit int) ([]*models.SyncChange, error) {
+	return measure("SyncEvents", func() ([]*models.SyncChange, error) { return m.repo.SyncEvents(userID, afterSeq, limit) })
+}
+
+func (m *MeasuredRepository) SearchEvents(viewerID string, calendarIDs []string, text string, languages [2]string, limit int) ([]*models.SearchResult, error) {
+	return measure("SearchEvents", func() ([]*models.SearchResult, error) {
+		return m.repo.SearchEvents(viewerID, calendarIDs, text, languages, limit)
+	})
+}
+
+func (m *MeasuredRepository) GetUserSettings(userID string) (*models.UserSettings, error) {
+	return measure("GetUserSettings", func() (*models.UserSettings, error) { return m.repo.GetUserSettings(userID) })
+}
+
+func (m *MeasuredRepository) GetUsersSettings(userIDs []string) ([]*models.UserSettings, error) {
+	return measure("GetUsersSettings", func() ([]*models.UserSettings, error) { return m.repo.GetUsersSettings(userIDs) })
+}
+
+func (m *MeasuredRepository) SaveUserSettings(settings *models.UserSettings) error {
+	return measureCall("SaveUserSettings", func() error { return m.repo.SaveUserSettings(settings) })
+}
+
+func (m *MeasuredRepository) GetTags(userID string) ([]*models.Tag, error) {
+	return measure("GetTags", func() ([]*models.Tag, error) { return m.repo.GetTags(userID) })
+}
+
+func (m *MeasuredRepository) RenameTag(tag *models.Tag) error {
+	return measureCall("RenameTag", func() error { return m.repo.RenameTag(tag) })
+}
+
+func (m *MeasuredRepository) MergeTags(merge *models.TagMerge) error {
+	return measureCall("MergeTags", func() error { return m.repo.MergeTags(merge) })
+}
+
+func (m *MeasuredRepository) DeleteTag(userID string, tagID string) error {
+	return measureCall("DeleteTag", func() error { return m.repo.DeleteTag(userID, tagID) })
+}
+
+func (m *MeasuredRepository) Report(query *models.ReportQuery) ([]*models.ReportRow, error) {
+	return measure("Report", func() ([]*models.ReportRow, error) { return m.repo.Report(query) })
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"Calendar/internal/models"
+	"Calendar/pkg/logger"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+type OutboxRepositoryInterface interface {
+	ProcessOutbox(limit int, handle func(event *models.DomainEvent) error) (int, error)
+	GetChangesSince(userID string, afterSeq int64, limit int) ([]*models.DomainEvent, error)
+}
+
+type OutboxRepository struct {
+	ctx context.Context
+	db  *pgxpool.Pool
+}
+
+func NewOutboxRepository(ctx context.Context, db *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{
+		ctx: ctx,
+		db:  db,
+	}
+}
+
+// ProcessOutbox locks up to limit unprocessed domain events and passes them to
+// handle, the changes of every calendar in seq order, which is their commit
+// order. A relay takes a transaction-scoped advisory lock on every calendar it
+// reads, so concurrent relays drain different calendars and can't publish one
+// out of order. Events handled successfully are marked processed; the first
+// failure stops the batch so that ordering is preserved on retry.
+func (r *OutboxRepository) ProcessOutbox(limit int, handle func(event *models.DomainEvent) error) (int, error) {
+	tx, err := r.db.Begin(r.ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error processing outbox: %w", err)
+	}
+	defer tx.Rollback(r.ctx)
+
+	userIDs, err := r.lockPendingCalendars(tx, limit)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error reading outbox", zap.Error(err))
+		return 0, fmt.Errorf("error reading outbox: %w", err)
+	}
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+	// The locks are taken before this statement, so it sees every change
+	// processed by the relay that held a calendar before.
+	rows, err := tx.Query(r.ctx,
+		"SELECT id, seq, event_type, event_id, user_id, before, after, created_at FROM outbox "+
+			"WHERE processed_at IS NULL AND user_id = ANY($1) ORDER BY user_id, seq LIMIT $2",
+		userIDs,
+		limit,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error reading outbox", zap.Error(err))
+		return 0, fmt.Errorf("error reading outbox: %w", err)
+	}
+	events, err := scanDomainEvents(rows)
+	if err != nil {
+		return 0, fmt.Errorf("error reading outbox: %w", err)
+	}
+
+	processed := 0
+	var handleErr error
+	for _, event := range events {
+		if handleErr = handle(event); handleErr != nil {
+			break
+		}
+		_, err := tx.Exec(r.ctx, "UPDATE outbox SET processed_at = now() WHERE id = $1", event.ID)
+		if err != nil {
+			logger.GetLoggerFromCtx(r.ctx).Error("error marking outbox processed", zap.Error(err))
+			return 0, fmt.Errorf("error marking outbox processed: %w", err)
+		}
+		processed++
+	}
+	if err := tx.Commit(r.ctx); err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error processing outbox", zap.Error(err))
+		return 0, fmt.Errorf("error processing outbox: %w", err)
+	}
+	if handleErr != nil {
+		return processed, fmt.Errorf("error publishing domain event: %w", handleErr)
+	}
+	return processed, nil
+}
+
+// lockPendingCalendars locks up to limit calendars with unprocessed changes,
+// the ones waiting longest first, and returns them. Calendars locked by
+// another relay are left out.
+func (r *OutboxRepository) lockPendingCalendars(tx pgx.Tx, limit int) ([]string, error) {
+	rows, err := tx.Query(r.ctx,
+		"SELECT user_id FROM outbox WHERE processed_at IS NULL GROUP BY user_id ORDER BY min(id) LIMIT $1",
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
+	}
+	var userIDs []string
+	for _, userID := range pending {
+		var locked bool
+		err := tx.QueryRow(r.ctx,
+			"SELECT pg_try_advisory_xact_lock(hashtext('outbox'), hashtext($1))",
+			userID,
+		).Scan(&locked)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
+
+// GetChangesSince returns the already published changes of the user's
+// calendar with a seq greater than afterSeq, oldest first.
+func (r *OutboxRepository) GetChangesSince(userID string, afterSeq int64, limit int) ([]*models.DomainEvent, error) {
+	rows, err := r.db.Query(r.ctx,
+		"SELECT id, seq, event_type, event_id, user_id, before, after, created_at FROM outbox "+
+			"WHERE user_id = $1 AND seq > $2 AND processed_at IS NOT NULL ORDER BY seq LIMIT $3",
+		userID,
+		afterSeq,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting changes: %w", err)
+	}
+	events, err := scanDomainEvents(rows)
+	if err != nil {
+		return nil, fmt.Errorf("error getting changes: %w", err)
+	}
+	return events, nil
+}
+
+func scanDomainEvents(rows pgx.Rows) ([]*models.DomainEvent, error) {
+	defer rows.Close()
+	var events []*models.DomainEvent
+	for rows.Next() {
+		var event models.DomainEvent
+		err := rows.Scan(
+			&event.ID,
+			&event.Seq,
+			&event.Type,
+			&event.EventID,
+			&event.UserID,
+			&event.Before,
+			&event.After,
+			&event.OccurredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
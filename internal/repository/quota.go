@@ -0,0 +1,45 @@
+package repository
+
+import "fmt"
+
+// CountEvents returns the number of events in the user's calendar, leaving
+// out the trash.
+func (r *CalendarRepository) CountEvents(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(r.ctx,
+		"SELECT count(*) FROM events WHERE user_id = $1 AND deleted_at IS NULL",
+		userID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting events: %w", err)
+	}
+	return count, nil
+}
+
+// GetCalendarsCreatedBy returns the calendars holding events the user
+// created, leaving out the trash. The creator is the actor of the first
+// revision of an event.
+func (r *CalendarRepository) GetCalendarsCreatedBy(actorID string) ([]string, error) {
+	var calendarIDs []string
+
+	rows, err := r.db.Query(r.ctx,
+		"SELECT DISTINCT e.user_id FROM events e JOIN event_history h ON h.event_id = e.event_id AND h.revision = 1 "+
+			"WHERE h.actor = $1 AND e.deleted_at IS NULL",
+		actorID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting calendars: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var calendarID string
+		if err := rows.Scan(&calendarID); err != nil {
+			return nil, fmt.Errorf("error getting calendars: %w", err)
+		}
+		calendarIDs = append(calendarIDs, calendarID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting calendars: %w", err)
+	}
+	return calendarIDs, nil
+}
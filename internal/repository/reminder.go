@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"Calendar/internal/models"
+	"Calendar/pkg/logger"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+type ReminderRepositoryInterface interface {
+	ClaimDueJobs(limit int, lease time.Duration) ([]*models.ReminderJob, error)
+	CompleteJob(jobID string) error
+	RetryJob(jobID string, runAt time.Time, reason string) error
+	FailJob(jobID string, reason string) error
+}
+
+type ReminderRepository struct {
+	ctx context.Context
+	db  *pgxpool.Pool
+}
+
+func NewReminderRepository(ctx context.Context, db *pgxpool.Pool) *ReminderRepository {
+	return &ReminderRepository{
+		ctx: ctx,
+		db:  db,
+	}
+}
+
+// ClaimDueJobs locks up to limit due jobs for the given lease. Jobs left in
+// processing by a worker that died are claimed again once their lease expires,
+// so nothing due is lost across restarts.
+func (r *ReminderRepository) ClaimDueJobs(limit int, lease time.Duration) ([]*models.ReminderJob, error) {
+	var jobs []*models.ReminderJob
+
+	rows, err := r.db.Query(r.ctx,
+		"UPDATE scheduled_jobs j SET status = 'processing', locked_until = now() + $2 * interval '1 second', attempts = j.attempts + 1 "+
+			"FROM reminders rm JOIN events e ON e.event_id = rm.event_id "+
+			"WHERE rm.reminder_id = j.reminder_id AND j.job_id IN ("+
+			"SELECT job_id FROM scheduled_jobs "+
+			"WHERE (status = 'pending' AND run_at <= now()) OR (status = 'processing' AND locked_until < now()) "+
+			"ORDER BY run_at LIMIT $1 FOR UPDATE SKIP LOCKED) "+
+			"RETURNING j.job_id, j.run_at, j.attempts, rm.reminder_id, rm.event_id, rm.offset_minutes, rm.channel, rm.target, "+
+			"e.user_id, e.event, e.date, COALESCE(to_char(e.start_time, 'HH24:MI'), '')",
+		limit,
+		lease.Seconds(),
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error claiming jobs", zap.Error(err))
+		return nil, fmt.Errorf("error claiming jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var d time.Time
+
+	for rows.Next() {
+		var job models.ReminderJob
+		err := rows.Scan(
+			&job.JobID,
+			&job.RunAt,
+			&job.Attempts,
+			&job.Reminder.ReminderID,
+			&job.Reminder.EventID,
+			&job.Reminder.OffsetMinutes,
+			&job.Reminder.Channel,
+			&job.Reminder.Target,
+			&job.Event.UserID,
+			&job.Event.Event,
+			&d,
+			&job.Event.StartTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		job.Event.EventID = job.Reminder.EventID
+		job.Event.Date = d.Format(time.DateOnly)
+		jobs = append(jobs, &job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error claiming jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+func (r *ReminderRepository) CompleteJob(jobID string) error {
+	_, err := r.db.Exec(r.ctx,
+		"UPDATE scheduled_jobs SET status = 'done', locked_until = NULL, completed_at = now() WHERE job_id = $1",
+		jobID,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error completing job", zap.Error(err))
+		return fmt.Errorf("error completing job: %w", err)
+	}
+	return nil
+}
+
+func (r *ReminderRepository) RetryJob(jobID string, runAt time.Time, reason string) error {
+	_, err := r.db.Exec(r.ctx,
+		"UPDATE scheduled_jobs SET status = 'pending', locked_until = NULL, run_at = $2, last_error = $3 WHERE job_id = $1",
+		jobID,
+		runAt,
+		reason,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error rescheduling job", zap.Error(err))
+		return fmt.Errorf("error rescheduling job: %w", err)
+	}
+	return nil
+}
+
+func (r *ReminderRepository) FailJob(jobID string, reason string) error {
+	_, err := r.db.Exec(r.ctx,
+		"UPDATE scheduled_jobs SET status = 'failed', locked_until = NULL, last_error = $2, completed_at = now() WHERE job_id = $1",
+		jobID,
+		reason,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error failing job", zap.Error(err))
+		return fmt.Errorf("error failing job: %w", err)
+	}
+	return nil
+}
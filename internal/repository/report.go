@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"Calendar/internal/models"
+	"fmt"
+	"time"
+)
+
+// Report sums the durations of the events of the calendars by bucket and
+// group. Only events with a start and an end time count; cancelled and
+// transparent events, which don't take up the time, are left out. Events of other users' calendars count if the viewer may see them
+// and, when grouped by tag or attendee, only if their details are public.
+func (r *CalendarRepository) Report(query *models.ReportQuery) ([]*models.ReportRow, error) {
+	var rows []*models.ReportRow
+
+	args := []any{query.CalendarIDs, query.From, query.To, query.ViewerID}
+	var bucket string
+	switch query.Bucket {
+	case models.BucketDay:
+		bucket = "e.date"
+	case models.BucketWeek:
+		// date_trunc weeks start on Monday; shifting the date moves the
+		// boundary to the requested day.
+		args = append(args, (8-int(query.WeekStart))%7)
+		bucket = fmt.Sprintf("(date_trunc('week', (e.date + $%[1]d::int)::timestamp)::date - $%[1]d::int)", len(args))
+	case models.BucketMonth:
+		bucket = "date_trunc('month', e.date::timestamp)::date"
+	default:
+		return nil, fmt.Errorf("unknown report bucket: %s", query.Bucket)
+	}
+	var key, join, group, visible string
+	switch query.GroupBy {
+	case models.GroupByCalendar:
+		key, group = "e.user_id", "e.user_id"
+		visible = "e.visibility <> 'private'"
+	case models.GroupByTag:
+		key, group = "COALESCE(min(t.name), '')", "lower(t.name)"
+		join = " LEFT JOIN event_tags et ON et.event_id = e.event_id LEFT JOIN tags t ON t.tag_id = et.tag_id"
+		visible = "e.visibility = 'public'"
+	case models.GroupByAttendee:
+		key, group = "COALESCE(a.attendee, '')", "a.attendee"
+		join = " LEFT JOIN event_attendees a ON a.event_id = e.event_id"
+		visible = "e.visibility = 'public'"
+	default:
+		return nil, fmt.Errorf("unknown report grouping: %s", query.GroupBy)
+	}
+
+	result, err := r.db.Query(r.ctx,
+		"SELECT "+bucket+" AS bucket, "+key+", "+
+			"sum(EXTRACT(EPOCH FROM e.end_time - e.start_time))::float8 / 3600, count(DISTINCT e.event_id) "+
+			"FROM events e"+join+" "+
+			"WHERE e.user_id = ANY($1) AND e.date BETWEEN $2 AND $3 AND e.deleted_at IS NULL AND e.status <> 'cancelled' "+
+			"AND e.transparency <> 'transparent' "+
+			"AND e.start_time IS NOT NULL AND e.end_time IS NOT NULL AND (e.user_id = $4 OR "+visible+") "+
+			"GROUP BY bucket, "+group+" ORDER BY bucket, 2",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error building report: %w", err)
+	}
+	defer result.Close()
+	for result.Next() {
+		var row models.ReportRow
+		var d time.Time
+		if err := result.Scan(&d, &row.Key, &row.Hours, &row.Events); err != nil {
+			return nil, err
+		}
+		row.Bucket = d.Format(time.DateOnly)
+		rows = append(rows, &row)
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("error building report: %w", err)
+	}
+	return rows, nil
+}
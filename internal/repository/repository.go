@@ -4,163 +4,869 @@ import (
 	"Calendar/internal/models"
 	"Calendar/pkg/logger"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
+	"strings"
 	"time"
 )
 
 type CalendarRepositoryInterface interface {
 	CreateEvent(event *models.Event) error
-	GetEventsForDay(userID string, date time.Time) ([]*models.Event, error)
-	GetEventsForWeek(userID string, date time.Time) ([]*models.Event, error)
-	GetEventsForMonth(userID string, date time.Time) ([]*models.Event, error)
-	DeleteEvent(eventId string) error
+	GetEvent(eventID string) (*models.Event, error)
+	GetTrash(userID string, limit int) ([]*models.Event, error)
+	RestoreEvent(eventID string) (*models.Event, error)
+	PurgeTrash(retention time.Duration, limit int) (int64, error)
+	QueryEvents(query *models.EventQuery) ([]*models.Event, error)
+	DeleteEvent(eventID string, version int64) error
 	UpdateEvent(event *models.Event) error
+	ApplyBatch(operations []*models.BatchOperation) error
+	GetHistory(eventID string) ([]*models.HistoryEntry, error)
+	Audit(query *models.AuditQuery) ([]*models.HistoryEntry, error)
+	RevertEvent(event *models.Event, revision int) error
+	CountEvents(userID string) (int, error)
+	GetCalendarsCreatedBy(actorID string) ([]string, error)
+	SyncEvents(userID string, afterSeq int64, limit int) ([]*models.SyncChange, error)
+	SearchEvents(viewerID string, calendarIDs []string, text string, languages [2]string, limit int) ([]*models.SearchResult, error)
+	GetUserSettings(userID string) (*models.UserSettings, error)
+	GetUsersSettings(userIDs []string) ([]*models.UserSettings, error)
+	SaveUserSettings(settings *models.UserSettings) error
+	GetTags(userID string) ([]*models.Tag, error)
+	RenameTag(tag *models.Tag) error
+	MergeTags(merge *models.TagMerge) error
+	DeleteTag(userID string, tagID string) error
+	Report(query *models.ReportQuery) ([]*models.ReportRow, error)
+}
+
+// querier is implemented by both the pool and transactions.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// ErrEventNotFound is returned when the event doesn't exist.
+var ErrEventNotFound = errors.New("no event found")
+
+// VersionConflictError is returned when an event is changed with a version
+// other than its current one.
+type VersionConflictError struct {
+	EventID string
+	Version int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("event %s has version %d", e.EventID, e.Version)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// eventColumns lists the columns read by scanEvent, in scan order.
+const eventColumns = "user_id, event_id, date, event, COALESCE(to_char(start_time, 'HH24:MI'), ''), " +
+	"COALESCE(to_char(end_time, 'HH24:MI'), ''), description, location, url, color, status, visibility, transparency, version"
+
+// QuotaExceededError is returned when a write would take a calendar over its
+// limit of events.
+type QuotaExceededError struct {
+	Resource string
+	Limit    int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota of %d exceeded", e.Resource, e.Limit)
 }
 
 type CalendarRepository struct {
-	ctx context.Context
-	db  *pgx.Conn
+	ctx       context.Context
+	db        *pgxpool.Pool
+	actor     string
+	maxEvents int
 }
 
-func NewCalendarRepository(ctx context.Context, db *pgx.Conn) *CalendarRepository {
+// NewCalendarRepository returns a repository that keeps every calendar at
+// most maxEvents events, not counting the trash; zero turns the limit off.
+func NewCalendarRepository(ctx context.Context, db *pgxpool.Pool, maxEvents int) *CalendarRepository {
 	return &CalendarRepository{
-		ctx: ctx,
-		db:  db,
+		ctx:       ctx,
+		db:        db,
+		maxEvents: maxEvents,
 	}
 }
 
 func (r *CalendarRepository) CreateEvent(event *models.Event) error {
+	tx, err := r.db.Begin(r.ctx)
+	if err != nil {
+		return fmt.Errorf("error creating event: %w", err)
+	}
+	defer tx.Rollback(r.ctx)
+	if err := r.createEvent(tx, event); err != nil {
+		return err
+	}
+	if err := tx.Commit(r.ctx); err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error creating event", zap.Error(err))
+		return fmt.Errorf("error creating event: %w", err)
+	}
+	return nil
+}
+
+func (r *CalendarRepository) createEvent(tx pgx.Tx, event *models.Event) error {
 	date, _ := time.Parse(time.DateOnly, event.Date)
-	_, err := r.db.Exec(r.ctx,
-		"INSERT INTO events (event_id, user_id,event, date) "+
-			"VALUES ($1, $2, $3, $4)",
+	seq, err := r.nextChangeSeq(tx, event.UserID)
+	if err != nil {
+		return fmt.Errorf("error creating event: %w", err)
+	}
+	if err := r.checkEventLimit(tx, event.UserID); err != nil {
+		return err
+	}
+	_, err = tx.Exec(r.ctx,
+		"INSERT INTO events (event_id, user_id,event, date, start_time, created_seq, change_seq, "+
+			"description, location, url, color, status, visibility, transparency, end_time) "+
+			"VALUES ($1, $2, $3, $4, NULLIF($5, '')::time, $6, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, '')::time)",
 		event.EventID,
 		event.UserID,
 		event.Event,
 		date,
+		event.StartTime,
+		seq,
+		event.Description,
+		event.Location,
+		event.URL,
+		event.Color,
+		event.Status,
+		event.Visibility,
+		event.Transparency,
+		event.EndTime,
 	)
 	if err != nil {
 		logger.GetLoggerFromCtx(r.ctx).Error("error creating event", zap.Error(err))
 		return fmt.Errorf("error creating event: %w", err)
 	}
+	event.Version = 1
+	if err := r.insertReminders(tx, event); err != nil {
+		return fmt.Errorf("error creating event: %w", err)
+	}
+	if err := r.setTags(tx, event); err != nil {
+		return fmt.Errorf("error creating event: %w", err)
+	}
+	if err := r.setAttendees(tx, event); err != nil {
+		return fmt.Errorf("error creating event: %w", err)
+	}
+	if err := r.insertOutbox(tx, models.EventCreated, nil, event); err != nil {
+		return fmt.Errorf("error creating event: %w", err)
+	}
+	if err := r.insertHistory(tx, models.HistoryCreated, nil, event, 0); err != nil {
+		return fmt.Errorf("error creating event: %w", err)
+	}
 	return nil
 }
 
-func (r *CalendarRepository) GetEventsForDay(userID string, date time.Time) ([]*models.Event, error) {
+// GetEvent returns the event with its tags, attendees and reminders.
+func (r *CalendarRepository) GetEvent(eventID string) (*models.Event, error) {
+	event, err := scanEvent(r.db.QueryRow(r.ctx,
+		"SELECT "+eventColumns+" FROM events WHERE event_id = $1 AND deleted_at IS NULL",
+		eventID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w with id: %s", ErrEventNotFound, eventID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting event: %w", err)
+	}
+	if err := r.attachRelated(r.db, []*models.Event{event}); err != nil {
+		return nil, fmt.Errorf("error getting event: %w", err)
+	}
+	if err := r.attachReminders(r.db, event); err != nil {
+		return nil, fmt.Errorf("error getting event: %w", err)
+	}
+	return event, nil
+}
+
+// QueryEvents returns up to query.Limit events ordered by date, start time and
+// id. Pagination is keyset based: query.After is the sort key of the last
+// event of the previous page. Private and transparent busy-only events are
+// only returned to their owner and the text filter only matches titles the
+// viewer is allowed to read.
+func (r *CalendarRepository) QueryEvents(query *models.EventQuery) ([]*models.Event, error) {
 	var events []*models.Event
 
-	rows, err := r.db.Query(r.ctx,
-		"SELECT event_id, user_id, event, date "+
-			"FROM events WHERE user_id = $1 AND date = $2",
-		userID,
-		date,
-	)
+	sql := "SELECT " + eventColumns + " " +
+		"FROM events WHERE user_id = ANY($1) AND date BETWEEN $2 AND $3 AND deleted_at IS NULL AND (user_id = $4 OR visibility = 'public' OR " +
+		"(visibility = 'busy' AND transparency <> 'transparent'))"
+	args := []any{query.CalendarIDs, query.From, query.To, query.ViewerID}
+	if query.Text != "" {
+		args = append(args, "%"+likeEscaper.Replace(query.Text)+"%")
+		sql += fmt.Sprintf(" AND event ILIKE $%d AND (user_id = $4 OR visibility = 'public')", len(args))
+	}
+	if len(query.Statuses) > 0 {
+		args = append(args, query.Statuses)
+		sql += fmt.Sprintf(" AND status = ANY($%d)", len(args))
+	}
+	if len(query.Tags) > 0 {
+		tags := make([]string, len(query.Tags))
+		for i, tag := range query.Tags {
+			tags[i] = strings.ToLower(tag)
+		}
+		args = append(args, tags)
+		sql += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM event_tags et JOIN tags t ON t.tag_id = et.tag_id "+
+			"WHERE et.event_id = events.event_id AND lower(t.name) = ANY($%d)) AND (user_id = $4 OR visibility = 'public')", len(args))
+	}
+	cmp, order := ">", "ASC"
+	if query.Desc {
+		cmp, order = "<", "DESC"
+	}
+	if query.After != nil {
+		args = append(args, query.After.Date, query.After.StartTime, query.After.EventID)
+		n := len(args)
+		sql += fmt.Sprintf(" AND (date, COALESCE(start_time, '00:00'::time), event_id) %s "+
+			"($%d::date, COALESCE(NULLIF($%d, '')::time, '00:00'::time), $%d)", cmp, n-2, n-1, n)
+	}
+	args = append(args, query.Limit)
+	sql += fmt.Sprintf(" ORDER BY date %[1]s, COALESCE(start_time, '00:00'::time) %[1]s, event_id %[1]s LIMIT $%[2]d", order, len(args))
+
+	rows, err := r.db.Query(r.ctx, sql, args...)
 	if err != nil {
-		return nil, fmt.Errorf("error getting events for day: %w", err)
+		return nil, fmt.Errorf("error querying events: %w", err)
 	}
 	defer rows.Close()
-
-	var d time.Time
-
 	for rows.Next() {
-		var event models.Event
-		err := rows.Scan(&event.EventID, &event.UserID, &event.Event, &d)
+		event, err := scanEvent(rows)
 		if err != nil {
 			return nil, err
 		}
-		event.Date = d.Format(time.DateOnly)
-		events = append(events, &event)
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying events: %w", err)
+	}
+	if err := r.attachRelated(r.db, events); err != nil {
+		return nil, fmt.Errorf("error querying events: %w", err)
 	}
-
 	return events, nil
 }
 
-func (r *CalendarRepository) GetEventsForWeek(userID string, date time.Time) ([]*models.Event, error) {
-	var events []*models.Event
-	endDate := date.AddDate(0, 0, 6).Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+// DeleteEvent moves the event to the trash if its current version is version.
+// Trashed events are left out of every read until they are restored or
+// purged.
+func (r *CalendarRepository) DeleteEvent(eventID string, version int64) error {
+	tx, err := r.db.Begin(r.ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting event: %w", err)
+	}
+	defer tx.Rollback(r.ctx)
+	if err := r.deleteEvent(tx, eventID, version); err != nil {
+		return err
+	}
+	if err := tx.Commit(r.ctx); err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error deleting event", zap.Any("error:", err))
+		return fmt.Errorf("error deleting event: %w", err)
+	}
+	return nil
+}
+
+func (r *CalendarRepository) deleteEvent(tx pgx.Tx, eventID string, version int64) error {
+	event, err := r.lockEvent(tx, eventID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w with id: %s", ErrEventNotFound, eventID)
+	}
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error deleting event", zap.Any("error:", err))
+		return fmt.Errorf("error deleting event: %w", err)
+	}
+	if event.Version != version {
+		return &VersionConflictError{EventID: eventID, Version: event.Version}
+	}
+	_, err = tx.Exec(r.ctx, "UPDATE events SET deleted_at = now() WHERE event_id = $1 AND version = $2", eventID, version)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error deleting event", zap.Any("error:", err))
+		return fmt.Errorf("error deleting event: %w", err)
+	}
+	// The reminders stay with the trashed event, but must not fire.
+	_, err = tx.Exec(r.ctx,
+		"DELETE FROM scheduled_jobs WHERE reminder_id IN (SELECT reminder_id FROM reminders WHERE event_id = $1)",
+		eventID,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error deleting event", zap.Any("error:", err))
+		return fmt.Errorf("error deleting event: %w", err)
+	}
+	if err := r.insertTombstone(tx, event.UserID, eventID); err != nil {
+		return fmt.Errorf("error deleting event: %w", err)
+	}
+	if err := r.insertOutbox(tx, models.EventDeleted, event, nil); err != nil {
+		return fmt.Errorf("error deleting event: %w", err)
+	}
+	if err := r.insertHistory(tx, models.HistoryDeleted, event, nil, 0); err != nil {
+		return fmt.Errorf("error deleting event: %w", err)
+	}
+	return nil
+}
+
+// UpdateEvent replaces the event if its current version is event.Version and
+// sets event.Version to the new version. The row stays locked from the
+// version check to the commit, so two concurrent updates of the same version
+// can't both succeed.
+func (r *CalendarRepository) UpdateEvent(event *models.Event) error {
+	tx, err := r.db.Begin(r.ctx)
+	if err != nil {
+		return fmt.Errorf("error updating event: %w", err)
+	}
+	defer tx.Rollback(r.ctx)
+	if err := r.updateEvent(tx, event); err != nil {
+		return err
+	}
+	if err := tx.Commit(r.ctx); err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error updating event", zap.Any("error:", err))
+		return fmt.Errorf("error updating event: %w", err)
+	}
+	return nil
+}
+
+func (r *CalendarRepository) updateEvent(tx pgx.Tx, event *models.Event) error {
+	return r.replaceEvent(tx, event, 0)
+}
+
+// replaceEvent updates the event, recording the change as a revert if
+// revertedTo is a revision.
+func (r *CalendarRepository) replaceEvent(tx pgx.Tx, event *models.Event, revertedTo int) error {
+	before, err := r.lockEvent(tx, event.EventID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w with id: %s", ErrEventNotFound, event.EventID)
+	}
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error updating event", zap.Any("error:", err))
+		return fmt.Errorf("error updating event: %w", err)
+	}
+	if before.Version != event.Version {
+		return &VersionConflictError{EventID: event.EventID, Version: before.Version}
+	}
+	// An event moved to another user disappears from the previous owner's
+	// calendar and shows up as created in the new one.
+	if before.UserID != event.UserID {
+		if err := r.insertTombstone(tx, before.UserID, event.EventID); err != nil {
+			return fmt.Errorf("error updating event: %w", err)
+		}
+		_, err = tx.Exec(r.ctx,
+			"DELETE FROM event_tombstones WHERE user_id = $1 AND event_id = $2",
+			event.UserID,
+			event.EventID,
+		)
+		if err != nil {
+			logger.GetLoggerFromCtx(r.ctx).Error("error updating event", zap.Any("error:", err))
+			return fmt.Errorf("error updating event: %w", err)
+		}
+	}
+	seq, err := r.nextChangeSeq(tx, event.UserID)
+	if err != nil {
+		return fmt.Errorf("error updating event: %w", err)
+	}
+	if before.UserID != event.UserID {
+		if err := r.checkEventLimit(tx, event.UserID); err != nil {
+			return err
+		}
+	}
+	err = tx.QueryRow(r.ctx,
+		"UPDATE events SET user_id = $1, event_id = $2, event = $3, date = $4, start_time = NULLIF($5, '')::time, "+
+			"created_seq = CASE WHEN user_id <> $1 THEN $7 ELSE created_seq END, change_seq = $7, "+
+			"description = $8, location = $9, url = $10, color = $11, status = $12, visibility = $13, transparency = $14, "+
+			"end_time = NULLIF($15, '')::time, version = version + 1 WHERE event_id = $6 AND version = $16 RETURNING version",
+		event.UserID,
+		event.EventID,
+		event.Event,
+		event.Date,
+		event.StartTime,
+		event.EventID,
+		seq,
+		event.Description,
+		event.Location,
+		event.URL,
+		event.Color,
+		event.Status,
+		event.Visibility,
+		event.Transparency,
+		event.EndTime,
+		event.Version,
+	).Scan(&event.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w with id: %s", ErrEventNotFound, event.EventID)
+	}
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error updating event", zap.Any("error:", err))
+		return fmt.Errorf("error updating event: %w", err)
+	}
+	// Reminders are replaced together with the event so that their jobs are
+	// rescheduled against the new date and start time.
+	_, err = tx.Exec(r.ctx, "DELETE FROM reminders WHERE event_id = $1", event.EventID)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error updating event", zap.Any("error:", err))
+		return fmt.Errorf("error updating event: %w", err)
+	}
+	if err := r.insertReminders(tx, event); err != nil {
+		return fmt.Errorf("error updating event: %w", err)
+	}
+	if err := r.setTags(tx, event); err != nil {
+		return fmt.Errorf("error updating event: %w", err)
+	}
+	if err := r.setAttendees(tx, event); err != nil {
+		return fmt.Errorf("error updating event: %w", err)
+	}
+	if err := r.insertOutbox(tx, models.EventUpdated, before, event); err != nil {
+		return fmt.Errorf("error updating event: %w", err)
+	}
+	action := models.HistoryUpdated
+	if revertedTo > 0 {
+		action = models.HistoryReverted
+	}
+	if err := r.insertHistory(tx, action, before, event, revertedTo); err != nil {
+		return fmt.Errorf("error updating event: %w", err)
+	}
+	return nil
+}
+
+// SearchEvents runs a full-text search over the title, location and
+// description of the events in calendarIDs whose text the viewer may read:
+// their own and the public events of others. The text is parsed with both
+// text search configurations in languages and matches are ranked with
+// ts_rank_cd, titles weighing more than locations and locations more than
+// descriptions. Matched words are wrapped in <mark> tags in the highlight and
+// the snippet holds the matching fragments of the description.
+func (r *CalendarRepository) SearchEvents(viewerID string, calendarIDs []string, text string, languages [2]string, limit int) ([]*models.SearchResult, error) {
+	var results []*models.SearchResult
+
 	rows, err := r.db.Query(r.ctx,
-		"SELECT user_id, event_id, date, event "+
-			"FROM events WHERE user_id = $1 AND date BETWEEN $2 AND $3",
-		userID,
-		date,
-		endDate,
+		"SELECT "+eventColumns+", "+
+			"ts_rank_cd(e.search_vector, q.query) AS rank, "+
+			"COALESCE(NULLIF(ts_headline($3::regconfig, e.event, q.first, $6), e.event), ts_headline($4::regconfig, e.event, q.second, $6)), "+
+			"CASE WHEN to_tsvector($3::regconfig, e.description) @@ q.first THEN ts_headline($3::regconfig, e.description, q.first, $7) "+
+			"WHEN to_tsvector($4::regconfig, e.description) @@ q.second THEN ts_headline($4::regconfig, e.description, q.second, $7) "+
+			"ELSE '' END "+
+			"FROM events e, (SELECT websearch_to_tsquery($3::regconfig, $2) AS first, websearch_to_tsquery($4::regconfig, $2) AS second, "+
+			"websearch_to_tsquery($3::regconfig, $2) || websearch_to_tsquery($4::regconfig, $2) AS query) q "+
+			"WHERE e.user_id = ANY($1) AND e.deleted_at IS NULL AND (e.user_id = $8 OR e.visibility = 'public') "+
+			"AND e.search_vector @@ q.query "+
+			"ORDER BY rank DESC, e.date DESC, e.event_id LIMIT $5",
+		calendarIDs,
+		text,
+		languages[0],
+		languages[1],
+		limit,
+		"StartSel=<mark>, StopSel=</mark>, HighlightAll=true",
+		"StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5",
+		viewerID,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("error getting events for week: %w", err)
+		return nil, fmt.Errorf("error searching events: %w", err)
 	}
-	var d time.Time
 	defer rows.Close()
 	for rows.Next() {
-		var event models.Event
-		err := rows.Scan(&event.UserID, &event.EventID, &d, &event.Event)
+		var result models.SearchResult
+		event, err := scanEvent(rows, &result.Rank, &result.Highlight, &result.Snippet)
 		if err != nil {
 			return nil, err
 		}
-		event.Date = d.Format(time.DateOnly)
-		events = append(events, &event)
+		result.Event = event
+		results = append(results, &result)
 	}
-	return events, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error searching events: %w", err)
+	}
+	events := make([]*models.Event, len(results))
+	for i, result := range results {
+		events[i] = result.Event
+	}
+	if err := r.attachRelated(r.db, events); err != nil {
+		return nil, fmt.Errorf("error searching events: %w", err)
+	}
+	return results, nil
 }
 
-func (r *CalendarRepository) GetEventsForMonth(userID string, date time.Time) ([]*models.Event, error) {
-	var events []*models.Event
-	endDate := date.AddDate(0, 1, 0)
+// GetUserSettings returns the stored settings of the user or the defaults if
+// the user never changed them.
+func (r *CalendarRepository) GetUserSettings(userID string) (*models.UserSettings, error) {
+	settings := models.UserSettings{UserID: userID, WeekStart: models.WeekStartMonday}
+	err := r.db.QueryRow(r.ctx,
+		"SELECT week_start FROM user_settings WHERE user_id = $1",
+		userID,
+	).Scan(&settings.WeekStart)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("error getting user settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// GetUsersSettings returns the settings of every user in userIDs, in the same
+// order, with the defaults for users who never changed them.
+func (r *CalendarRepository) GetUsersSettings(userIDs []string) ([]*models.UserSettings, error) {
+	stored := make(map[string]string, len(userIDs))
 	rows, err := r.db.Query(r.ctx,
-		"SELECT user_id, event_id, date, event "+
-			"FROM events WHERE user_id = $1 AND date BETWEEN $2 AND $3",
+		"SELECT user_id, week_start FROM user_settings WHERE user_id = ANY($1)",
+		userIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user settings: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID, weekStart string
+		if err := rows.Scan(&userID, &weekStart); err != nil {
+			return nil, fmt.Errorf("error scanning user settings: %w", err)
+		}
+		stored[userID] = weekStart
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting user settings: %w", err)
+	}
+	settings := make([]*models.UserSettings, len(userIDs))
+	for i, userID := range userIDs {
+		settings[i] = &models.UserSettings{UserID: userID, WeekStart: models.WeekStartMonday}
+		if weekStart, ok := stored[userID]; ok {
+			settings[i].WeekStart = weekStart
+		}
+	}
+	return settings, nil
+}
+
+func (r *CalendarRepository) SaveUserSettings(settings *models.UserSettings) error {
+	_, err := r.db.Exec(r.ctx,
+		"INSERT INTO user_settings (user_id, week_start) VALUES ($1, $2) "+
+			"ON CONFLICT (user_id) DO UPDATE SET week_start = EXCLUDED.week_start",
+		settings.UserID,
+		settings.WeekStart,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error saving user settings", zap.Error(err))
+		return fmt.Errorf("error saving user settings: %w", err)
+	}
+	return nil
+}
+
+// SyncEvents returns the changes of the user's calendar with a change sequence
+// greater than afterSeq in sequence order. A negative afterSeq requests a full
+// sync, which contains every event and no tombstones.
+func (r *CalendarRepository) SyncEvents(userID string, afterSeq int64, limit int) ([]*models.SyncChange, error) {
+	var changes []*models.SyncChange
+
+	rows, err := r.db.Query(r.ctx,
+		"SELECT * FROM ("+
+			"SELECT "+eventColumns+", change_seq AS seq, created_seq, NULL::timestamptz AS deleted_at "+
+			"FROM events WHERE user_id = $1 AND change_seq > $2 AND deleted_at IS NULL "+
+			"UNION ALL "+
+			"SELECT user_id, event_id, NULL, '', '', '', '', '', '', '', '', '', '', 0, change_seq, 0, deleted_at "+
+			"FROM event_tombstones WHERE user_id = $1 AND change_seq > $2 AND $2 >= 0"+
+			") c ORDER BY seq, event_id LIMIT $3",
 		userID,
-		date,
-		endDate,
+		afterSeq,
+		limit,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("error getting events for week: %w", err)
+		return nil, fmt.Errorf("error syncing events: %w", err)
 	}
-	var d time.Time
 	defer rows.Close()
+
 	for rows.Next() {
-		var event models.Event
-		err := rows.Scan(&event.UserID, &event.EventID, &d, &event.Event)
+		var (
+			change    models.SyncChange
+			deletedAt *time.Time
+		)
+		event, err := scanEvent(rows, &change.Seq, &change.CreatedSeq, &deletedAt)
 		if err != nil {
 			return nil, err
 		}
-		event.Date = d.Format(time.DateOnly)
-		events = append(events, &event)
+		if deletedAt != nil {
+			change.Tombstone = &models.Tombstone{EventID: event.EventID, DeletedAt: *deletedAt}
+		} else {
+			change.Event = event
+		}
+		changes = append(changes, &change)
 	}
-	return events, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error syncing events: %w", err)
+	}
+	var events []*models.Event
+	for _, change := range changes {
+		if change.Event != nil {
+			events = append(events, change.Event)
+		}
+	}
+	if err := r.attachRelated(r.db, events); err != nil {
+		return nil, fmt.Errorf("error syncing events: %w", err)
+	}
+
+	return changes, nil
+}
+
+// nextChangeSeq increments the user's change sequence. The counter row stays
+// locked until the transaction ends, so sequence values of one user become
+// visible in the order they were assigned and a sync token never skips a
+// change that commits later.
+func (r *CalendarRepository) nextChangeSeq(tx pgx.Tx, userID string) (int64, error) {
+	var seq int64
+	err := tx.QueryRow(r.ctx,
+		"INSERT INTO user_change_sequences (user_id, value) VALUES ($1, 1) "+
+			"ON CONFLICT (user_id) DO UPDATE SET value = user_change_sequences.value + 1 RETURNING value",
+		userID,
+	).Scan(&seq)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error incrementing change sequence", zap.Error(err))
+		return 0, fmt.Errorf("error incrementing change sequence: %w", err)
+	}
+	return seq, nil
+}
+
+// checkEventLimit checks that the calendar of userID has room for one more
+// event. It must run after nextChangeSeq locked the user's sequence row,
+// which holds back every other write to the calendar until the transaction
+// ends, so concurrent creates can't both see the last free place.
+func (r *CalendarRepository) checkEventLimit(tx pgx.Tx, userID string) error {
+	if r.maxEvents <= 0 {
+		return nil
+	}
+	var count int
+	err := tx.QueryRow(r.ctx,
+		"SELECT count(*) FROM events WHERE user_id = $1 AND deleted_at IS NULL",
+		userID,
+	).Scan(&count)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error counting events", zap.Error(err))
+		return fmt.Errorf("error counting events: %w", err)
+	}
+	if count >= r.maxEvents {
+		return &QuotaExceededError{Resource: "events", Limit: r.maxEvents}
+	}
+	return nil
 }
 
-func (r *CalendarRepository) DeleteEvent(eventID string) error {
-	res, err := r.db.Exec(r.ctx,
-		"DELETE FROM events WHERE event_id = $1",
+func (r *CalendarRepository) insertTombstone(tx pgx.Tx, userID string, eventID string) error {
+	seq, err := r.nextChangeSeq(tx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(r.ctx,
+		"INSERT INTO event_tombstones (event_id, user_id, change_seq) VALUES ($1, $2, $3) "+
+			"ON CONFLICT (user_id, event_id) DO UPDATE SET change_seq = EXCLUDED.change_seq, deleted_at = now()",
 		eventID,
+		userID,
+		seq,
 	)
 	if err != nil {
-		logger.GetLoggerFromCtx(r.ctx).Error("error deleting event", zap.Any("error:", err))
-		return fmt.Errorf("error deleting event: %w", err)
+		logger.GetLoggerFromCtx(r.ctx).Error("error writing tombstone", zap.Error(err))
+		return fmt.Errorf("error writing tombstone: %w", err)
+	}
+	return nil
+}
+
+// insertReminders stores the event reminders and schedules a job for every
+// reminder whose fire time is still ahead. Reminders that are already due are
+// kept but never fired, so editing an event does not repeat old notifications.
+// Reminders of cancelled events are kept without jobs.
+func (r *CalendarRepository) insertReminders(tx pgx.Tx, event *models.Event) error {
+	start, err := eventStart(event)
+	if err != nil {
+		return err
 	}
-	if res.RowsAffected() == 0 {
-		return fmt.Errorf("no event found with id: %s", eventID)
+	now := time.Now()
+	for _, reminder := range event.Reminders {
+		reminder.ReminderID = uuid.New().String()
+		reminder.EventID = event.EventID
+		_, err := tx.Exec(r.ctx,
+			"INSERT INTO reminders (reminder_id, event_id, offset_minutes, channel, target) "+
+				"VALUES ($1, $2, $3, $4, $5)",
+			reminder.ReminderID,
+			reminder.EventID,
+			reminder.OffsetMinutes,
+			reminder.Channel,
+			reminder.Target,
+		)
+		if err != nil {
+			logger.GetLoggerFromCtx(r.ctx).Error("error creating reminder", zap.Error(err))
+			return fmt.Errorf("error creating reminder: %w", err)
+		}
+		runAt := start.Add(-time.Duration(reminder.OffsetMinutes) * time.Minute)
+		if !runAt.After(now) || event.Status == models.StatusCancelled {
+			continue
+		}
+		_, err = tx.Exec(r.ctx,
+			"INSERT INTO scheduled_jobs (job_id, reminder_id, run_at) VALUES ($1, $2, $3)",
+			uuid.New().String(),
+			reminder.ReminderID,
+			runAt,
+		)
+		if err != nil {
+			logger.GetLoggerFromCtx(r.ctx).Error("error scheduling reminder", zap.Error(err))
+			return fmt.Errorf("error scheduling reminder: %w", err)
+		}
 	}
 	return nil
 }
 
-func (r *CalendarRepository) UpdateEvent(event *models.Event) error {
-	res, err := r.db.Exec(r.ctx,
-		"UPDATE events SET user_id = $1, event_id = $2, event = $3, date = $4 WHERE event_id = $5",
-		event.UserID,
+// insertOutbox records the change as a domain event in the same transaction
+// as the event row, so a change is published if and only if it was committed.
+// The change is numbered from the user's change sequence, which stays locked
+// until the transaction ends, so the changes of a calendar commit in the order
+// of their seq. An event moved to another user is also recorded as deleted
+// from the previous owner's calendar.
+func (r *CalendarRepository) insertOutbox(tx pgx.Tx, eventType string, before, after *models.Event) error {
+	if before != nil && after != nil && before.UserID != after.UserID {
+		if err := r.writeOutbox(tx, models.EventDeleted, before.UserID, before.EventID, before, nil); err != nil {
+			return err
+		}
+	}
+	current := after
+	if current == nil {
+		current = before
+	}
+	return r.writeOutbox(tx, eventType, current.UserID, current.EventID, before, after)
+}
+
+func (r *CalendarRepository) writeOutbox(tx pgx.Tx, eventType, userID, eventID string, before, after *models.Event) error {
+	seq, err := r.nextChangeSeq(tx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(r.ctx,
+		"INSERT INTO outbox (event_type, user_id, event_id, seq, before, after) VALUES ($1, $2, $3, $4, $5, $6)",
+		eventType,
+		userID,
+		eventID,
+		seq,
+		before,
+		after,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error writing outbox", zap.Error(err))
+		return fmt.Errorf("error writing outbox: %w", err)
+	}
+	return nil
+}
+
+// lockEvent reads the current state of the event with its tags, attendees and
+// reminders and locks the row until the transaction ends.
+func (r *CalendarRepository) lockEvent(tx pgx.Tx, eventID string) (*models.Event, error) {
+	event, err := scanEvent(tx.QueryRow(r.ctx,
+		"SELECT "+eventColumns+" FROM events WHERE event_id = $1 AND deleted_at IS NULL FOR UPDATE",
+		eventID,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.attachRelated(tx, []*models.Event{event}); err != nil {
+		return nil, err
+	}
+	if err := r.attachReminders(tx, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// attachReminders loads the reminders of the event.
+func (r *CalendarRepository) attachReminders(q querier, event *models.Event) error {
+	rows, err := q.Query(r.ctx,
+		"SELECT reminder_id, event_id, offset_minutes, channel, target FROM reminders "+
+			"WHERE event_id = $1 ORDER BY offset_minutes, reminder_id",
 		event.EventID,
-		event.Event,
-		event.Date,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var reminder models.Reminder
+		err := rows.Scan(&reminder.ReminderID, &reminder.EventID, &reminder.OffsetMinutes, &reminder.Channel, &reminder.Target)
+		if err != nil {
+			return err
+		}
+		event.Reminders = append(event.Reminders, &reminder)
+	}
+	return rows.Err()
+}
+
+// attachRelated loads the tags and attendees of the events.
+func (r *CalendarRepository) attachRelated(q querier, events []*models.Event) error {
+	if err := r.attachTags(q, events); err != nil {
+		return err
+	}
+	return r.attachAttendees(q, events)
+}
+
+// setAttendees replaces the attendees of the event.
+func (r *CalendarRepository) setAttendees(tx pgx.Tx, event *models.Event) error {
+	_, err := tx.Exec(r.ctx, "DELETE FROM event_attendees WHERE event_id = $1", event.EventID)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error saving attendees", zap.Error(err))
+		return fmt.Errorf("error saving attendees: %w", err)
+	}
+	if len(event.Attendees) == 0 {
+		return nil
+	}
+	_, err = tx.Exec(r.ctx,
+		"INSERT INTO event_attendees (event_id, attendee) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING",
 		event.EventID,
+		event.Attendees,
 	)
 	if err != nil {
-		logger.GetLoggerFromCtx(r.ctx).Error("error updating event", zap.Any("error:", err))
-		return fmt.Errorf("error updating event: %w", err)
+		logger.GetLoggerFromCtx(r.ctx).Error("error saving attendees", zap.Error(err))
+		return fmt.Errorf("error saving attendees: %w", err)
+	}
+	return nil
+}
+
+// attachAttendees loads the attendees of the events, ordered by attendee.
+func (r *CalendarRepository) attachAttendees(q querier, events []*models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	byID := make(map[string]*models.Event, len(events))
+	eventIDs := make([]string, 0, len(events))
+	for _, event := range events {
+		byID[event.EventID] = event
+		eventIDs = append(eventIDs, event.EventID)
+	}
+	rows, err := q.Query(r.ctx,
+		"SELECT event_id, attendee FROM event_attendees WHERE event_id = ANY($1) ORDER BY attendee",
+		eventIDs,
+	)
+	if err != nil {
+		return fmt.Errorf("error getting attendees: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var eventID, attendee string
+		if err := rows.Scan(&eventID, &attendee); err != nil {
+			return err
+		}
+		event := byID[eventID]
+		event.Attendees = append(event.Attendees, attendee)
 	}
-	if res.RowsAffected() == 0 {
-		return fmt.Errorf("no event found with id: %s", event.EventID)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error getting attendees: %w", err)
 	}
 	return nil
 }
+
+// scanEvent reads a row that starts with eventColumns. Any further columns are
+// scanned into extra.
+func scanEvent(row pgx.Row, extra ...any) (*models.Event, error) {
+	var event models.Event
+	var d *time.Time
+	dest := []any{
+		&event.UserID, &event.EventID, &d, &event.Event, &event.StartTime, &event.EndTime,
+		&event.Description, &event.Location, &event.URL, &event.Color,
+		&event.Status, &event.Visibility, &event.Transparency, &event.Version,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if d != nil {
+		event.Date = d.Format(time.DateOnly)
+	}
+	return &event, nil
+}
+
+// eventStart returns the moment the event begins in UTC. Events without a
+// start time begin at midnight of their date.
+func eventStart(event *models.Event) (time.Time, error) {
+	if event.StartTime == "" {
+		return time.Parse(time.DateOnly, event.Date)
+	}
+	return time.Parse(time.DateOnly+" 15:04", event.Date+" "+event.StartTime)
+}
@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"Calendar/internal/models"
+	"Calendar/pkg/logger"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+// ErrTagExists is returned when a tag is renamed to the name of another tag of
+// the same user.
+var ErrTagExists = errors.New("tag already exists")
+
+// GetTags returns the user's tag catalogue ordered by name, with the number of
+// events carrying each tag.
+func (r *CalendarRepository) GetTags(userID string) ([]*models.Tag, error) {
+	var tags []*models.Tag
+
+	rows, err := r.db.Query(r.ctx,
+		"SELECT t.tag_id, t.user_id, t.name, count(e.event_id) "+
+			"FROM tags t LEFT JOIN event_tags et ON et.tag_id = t.tag_id "+
+			"LEFT JOIN events e ON e.event_id = et.event_id AND e.deleted_at IS NULL "+
+			"WHERE t.user_id = $1 GROUP BY t.tag_id ORDER BY lower(t.name)",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting tags: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.TagID, &tag.UserID, &tag.Name, &tag.EventCount); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting tags: %w", err)
+	}
+	return tags, nil
+}
+
+func (r *CalendarRepository) RenameTag(tag *models.Tag) error {
+	tx, err := r.db.Begin(r.ctx)
+	if err != nil {
+		return fmt.Errorf("error renaming tag: %w", err)
+	}
+	defer tx.Rollback(r.ctx)
+	if err := r.checkTags(tx, tag.UserID, tag.TagID); err != nil {
+		return err
+	}
+	err = r.retagEvents(tx, []string{tag.TagID}, func() error {
+		_, err := tx.Exec(r.ctx,
+			"UPDATE tags SET name = $3 WHERE tag_id = $1 AND user_id = $2",
+			tag.TagID,
+			tag.UserID,
+			tag.Name,
+		)
+		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrTagExists
+	}
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error renaming tag", zap.Error(err))
+		return fmt.Errorf("error renaming tag: %w", err)
+	}
+	if err := tx.Commit(r.ctx); err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error renaming tag", zap.Error(err))
+		return fmt.Errorf("error renaming tag: %w", err)
+	}
+	return nil
+}
+
+func (r *CalendarRepository) MergeTags(merge *models.TagMerge) error {
+	tx, err := r.db.Begin(r.ctx)
+	if err != nil {
+		return fmt.Errorf("error merging tags: %w", err)
+	}
+	defer tx.Rollback(r.ctx)
+	if err := r.checkTags(tx, merge.UserID, merge.SourceTagID, merge.TargetTagID); err != nil {
+		return err
+	}
+	err = r.retagEvents(tx, []string{merge.SourceTagID}, func() error {
+		_, err := tx.Exec(r.ctx,
+			"INSERT INTO event_tags (event_id, tag_id) SELECT event_id, $2 FROM event_tags WHERE tag_id = $1 "+
+				"ON CONFLICT DO NOTHING",
+			merge.SourceTagID,
+			merge.TargetTagID,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(r.ctx, "DELETE FROM tags WHERE tag_id = $1", merge.SourceTagID)
+		return err
+	})
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error merging tags", zap.Error(err))
+		return fmt.Errorf("error merging tags: %w", err)
+	}
+	if err := tx.Commit(r.ctx); err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error merging tags", zap.Error(err))
+		return fmt.Errorf("error merging tags: %w", err)
+	}
+	return nil
+}
+
+func (r *CalendarRepository) DeleteTag(userID string, tagID string) error {
+	tx, err := r.db.Begin(r.ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting tag: %w", err)
+	}
+	defer tx.Rollback(r.ctx)
+	if err := r.checkTags(tx, userID, tagID); err != nil {
+		return err
+	}
+	err = r.retagEvents(tx, []string{tagID}, func() error {
+		_, err := tx.Exec(r.ctx, "DELETE FROM tags WHERE tag_id = $1", tagID)
+		return err
+	})
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error deleting tag", zap.Error(err))
+		return fmt.Errorf("error deleting tag: %w", err)
+	}
+	if err := tx.Commit(r.ctx); err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error deleting tag", zap.Error(err))
+		return fmt.Errorf("error deleting tag: %w", err)
+	}
+	return nil
+}
+
+// checkTags makes sure every tag exists and belongs to the user.
+func (r *CalendarRepository) checkTags(tx pgx.Tx, userID string, tagIDs ...string) error {
+	for _, tagID := range tagIDs {
+		var found bool
+		err := tx.QueryRow(r.ctx,
+			"SELECT EXISTS (SELECT 1 FROM tags WHERE tag_id = $1 AND user_id = $2)",
+			tagID,
+			userID,
+		).Scan(&found)
+		if err != nil {
+			return fmt.Errorf("error getting tag: %w", err)
+		}
+		if !found {
+			return fmt.Errorf("no tag found with id: %s", tagID)
+		}
+	}
+	return nil
+}
+
+// retagEvents runs change, which modifies the given tags, and records every
+// event carrying one of them as updated, so that sync clients and subscribers
+// see the new tags. Events are locked before the tags to keep the lock order
+// of event updates. Trashed events keep their tags but aren't recorded; they
+// show the new tags once restored.
+func (r *CalendarRepository) retagEvents(tx pgx.Tx, tagIDs []string, change func() error) error {
+	rows, err := tx.Query(r.ctx,
+		"SELECT DISTINCT et.event_id FROM event_tags et JOIN events e ON e.event_id = et.event_id "+
+			"WHERE et.tag_id = ANY($1) AND e.deleted_at IS NULL ORDER BY et.event_id",
+		tagIDs,
+	)
+	if err != nil {
+		return err
+	}
+	var eventIDs []string
+	for rows.Next() {
+		var eventID string
+		if err := rows.Scan(&eventID); err != nil {
+			rows.Close()
+			return err
+		}
+		eventIDs = append(eventIDs, eventID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	before := make([]*models.Event, 0, len(eventIDs))
+	for _, eventID := range eventIDs {
+		event, err := r.lockEvent(tx, eventID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Trashed since the select.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		before = append(before, event)
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	after := make([]*models.Event, len(before))
+	for i, event := range before {
+		updated := *event
+		updated.Tags = nil
+		updated.Version++
+		after[i] = &updated
+	}
+	if err := r.attachTags(tx, after); err != nil {
+		return err
+	}
+	for i, event := range after {
+		seq, err := r.nextChangeSeq(tx, event.UserID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(r.ctx, "UPDATE events SET change_seq = $2, version = version + 1 WHERE event_id = $1", event.EventID, seq)
+		if err != nil {
+			return err
+		}
+		if err := r.insertOutbox(tx, models.EventUpdated, before[i], event); err != nil {
+			return err
+		}
+		if err := r.insertHistory(tx, models.HistoryUpdated, before[i], event, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTags replaces the tags of the event. Names missing from the owner's
+// catalogue are added to it; known names take the spelling stored there.
+func (r *CalendarRepository) setTags(tx pgx.Tx, event *models.Event) error {
+	_, err := tx.Exec(r.ctx, "DELETE FROM event_tags WHERE event_id = $1", event.EventID)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error tagging event", zap.Error(err))
+		return fmt.Errorf("error tagging event: %w", err)
+	}
+	names := make([]string, 0, len(event.Tags))
+	for _, name := range event.Tags {
+		var tagID string
+		err := tx.QueryRow(r.ctx,
+			"INSERT INTO tags (tag_id, user_id, name) VALUES ($1, $2, $3) "+
+				"ON CONFLICT (user_id, lower(name)) DO UPDATE SET name = tags.name RETURNING tag_id, name",
+			uuid.New().String(),
+			event.UserID,
+			name,
+		).Scan(&tagID, &name)
+		if err != nil {
+			logger.GetLoggerFromCtx(r.ctx).Error("error tagging event", zap.Error(err))
+			return fmt.Errorf("error tagging event: %w", err)
+		}
+		_, err = tx.Exec(r.ctx,
+			"INSERT INTO event_tags (event_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+			event.EventID,
+			tagID,
+		)
+		if err != nil {
+			logger.GetLoggerFromCtx(r.ctx).Error("error tagging event", zap.Error(err))
+			return fmt.Errorf("error tagging event: %w", err)
+		}
+		names = append(names, name)
+	}
+	if len(names) > 0 {
+		event.Tags = names
+	}
+	return nil
+}
+
+// attachTags loads the tag names of the events, ordered by name.
+func (r *CalendarRepository) attachTags(q querier, events []*models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	byID := make(map[string]*models.Event, len(events))
+	eventIDs := make([]string, 0, len(events))
+	for _, event := range events {
+		byID[event.EventID] = event
+		eventIDs = append(eventIDs, event.EventID)
+	}
+	rows, err := q.Query(r.ctx,
+		"SELECT et.event_id, t.name FROM event_tags et JOIN tags t ON t.tag_id = et.tag_id "+
+			"WHERE et.event_id = ANY($1) ORDER BY lower(t.name)",
+		eventIDs,
+	)
+	if err != nil {
+		return fmt.Errorf("error getting event tags: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var eventID, name string
+		if err := rows.Scan(&eventID, &name); err != nil {
+			return err
+		}
+		event := byID[eventID]
+		event.Tags = append(event.Tags, name)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error getting event tags: %w", err)
+	}
+	return nil
+}
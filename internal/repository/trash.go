@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"Calendar/internal/models"
+	"Calendar/pkg/logger"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+	"time"
+)
+
+// GetTrash returns up to limit trashed events of the user, the most recently
+// deleted first.
+func (r *CalendarRepository) GetTrash(userID string, limit int) ([]*models.Event, error) {
+	var events []*models.Event
+
+	rows, err := r.db.Query(r.ctx,
+		"SELECT "+eventColumns+", deleted_at FROM events WHERE user_id = $1 AND deleted_at IS NOT NULL "+
+			"ORDER BY deleted_at DESC, event_id LIMIT $2",
+		userID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting trash: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var deletedAt time.Time
+		event, err := scanEvent(rows, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+		event.DeletedAt = &deletedAt
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting trash: %w", err)
+	}
+	if err := r.attachRelated(r.db, events); err != nil {
+		return nil, fmt.Errorf("error getting trash: %w", err)
+	}
+	return events, nil
+}
+
+// RestoreEvent takes the event out of the trash with a new version. Its
+// reminders are scheduled again, and sync clients and subscribers see it as
+// created.
+func (r *CalendarRepository) RestoreEvent(eventID string) (*models.Event, error) {
+	tx, err := r.db.Begin(r.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	defer tx.Rollback(r.ctx)
+	event, err := scanEvent(tx.QueryRow(r.ctx,
+		"SELECT "+eventColumns+" FROM events WHERE event_id = $1 AND deleted_at IS NOT NULL FOR UPDATE",
+		eventID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w with id: %s", ErrEventNotFound, eventID)
+	}
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error restoring event", zap.Error(err))
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	if err := r.attachRelated(tx, []*models.Event{event}); err != nil {
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	if err := r.attachReminders(tx, event); err != nil {
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	seq, err := r.nextChangeSeq(tx, event.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	if err := r.checkEventLimit(tx, event.UserID); err != nil {
+		return nil, err
+	}
+	err = tx.QueryRow(r.ctx,
+		"UPDATE events SET deleted_at = NULL, created_seq = $2, change_seq = $2, version = version + 1 "+
+			"WHERE event_id = $1 RETURNING version",
+		eventID,
+		seq,
+	).Scan(&event.Version)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error restoring event", zap.Error(err))
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	_, err = tx.Exec(r.ctx,
+		"DELETE FROM event_tombstones WHERE user_id = $1 AND event_id = $2",
+		event.UserID,
+		eventID,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error restoring event", zap.Error(err))
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	_, err = tx.Exec(r.ctx, "DELETE FROM reminders WHERE event_id = $1", eventID)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error restoring event", zap.Error(err))
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	if err := r.insertReminders(tx, event); err != nil {
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	if err := r.insertOutbox(tx, models.EventCreated, nil, event); err != nil {
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	if err := r.insertHistory(tx, models.HistoryRestored, nil, event, 0); err != nil {
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	if err := tx.Commit(r.ctx); err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error restoring event", zap.Error(err))
+		return nil, fmt.Errorf("error restoring event: %w", err)
+	}
+	return event, nil
+}
+
+// PurgeTrash permanently deletes up to limit events that have been in the
+// trash for longer than retention and returns how many it deleted.
+func (r *CalendarRepository) PurgeTrash(retention time.Duration, limit int) (int64, error) {
+	tag, err := r.db.Exec(r.ctx,
+		"DELETE FROM events WHERE event_id IN (SELECT event_id FROM events "+
+			"WHERE deleted_at <= now() - make_interval(secs => $1) LIMIT $2)",
+		retention.Seconds(),
+		limit,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error purging trash", zap.Error(err))
+		return 0, fmt.Errorf("error purging trash: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
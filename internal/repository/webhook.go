@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"Calendar/internal/models"
+	"Calendar/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+	"time"
+)
+
+type WebhookRepositoryInterface interface {
+	CreateSubscription(sub *models.WebhookSubscription) error
+	GetSubscriptions(userID string) ([]*models.WebhookSubscription, error)
+	DeleteSubscription(userID string, subscriptionID string) error
+	GetDeliveries(userID string, subscriptionID string, status string, limit int) ([]*models.WebhookDelivery, error)
+	GetDeadLetters(userID string, limit int) ([]*models.WebhookDelivery, error)
+	Redeliver(userID string, deliveryID string) error
+	EnqueueDeliveries(event *models.DomainEvent) error
+	ClaimDeliveries(limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	MarkDelivered(deliveryID string, responseStatus int) error
+	RetryDelivery(deliveryID string, nextAttemptAt time.Time, responseStatus int, reason string) error
+	MarkDead(deliveryID string, responseStatus int, reason string) error
+}
+
+// ErrWebhookNotFound is returned when the user has no webhook with the id.
+var ErrWebhookNotFound = errors.New("no webhook found")
+
+// ErrDeliveryNotFound is returned when no webhook of the user has a dead
+// delivery with the id.
+var ErrDeliveryNotFound = errors.New("no dead delivery found")
+
+type WebhookRepository struct {
+	ctx context.Context
+	db  *pgxpool.Pool
+}
+
+func NewWebhookRepository(ctx context.Context, db *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{
+		ctx: ctx,
+		db:  db,
+	}
+}
+
+const deliveryColumns = "d.delivery_id, d.subscription_id, d.event_type, d.payload, d.status, d.attempts, " +
+	"d.next_attempt_at, d.response_status, d.last_error, d.created_at, d.delivered_at"
+
+func (r *WebhookRepository) CreateSubscription(sub *models.WebhookSubscription) error {
+	err := r.db.QueryRow(r.ctx,
+		"INSERT INTO webhook_subscriptions (subscription_id, user_id, url, event_types, secret) "+
+			"VALUES ($1, $2, $3, $4, $5) RETURNING created_at",
+		sub.SubscriptionID,
+		sub.UserID,
+		sub.URL,
+		sub.EventTypes,
+		sub.Secret,
+	).Scan(&sub.CreatedAt)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error creating webhook", zap.Error(err))
+		return fmt.Errorf("error creating webhook: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetSubscriptions(userID string) ([]*models.WebhookSubscription, error) {
+	var subs []*models.WebhookSubscription
+
+	rows, err := r.db.Query(r.ctx,
+		"SELECT subscription_id, user_id, url, event_types, created_at "+
+			"FROM webhook_subscriptions WHERE user_id = $1 ORDER BY created_at",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sub models.WebhookSubscription
+		err := rows.Scan(&sub.SubscriptionID, &sub.UserID, &sub.URL, &sub.EventTypes, &sub.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, &sub)
+	}
+
+	return subs, nil
+}
+
+func (r *WebhookRepository) DeleteSubscription(userID string, subscriptionID string) error {
+	res, err := r.db.Exec(r.ctx,
+		"DELETE FROM webhook_subscriptions WHERE subscription_id = $1 AND user_id = $2",
+		subscriptionID,
+		userID,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error deleting webhook", zap.Error(err))
+		return fmt.Errorf("error deleting webhook: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("%w with id: %s", ErrWebhookNotFound, subscriptionID)
+	}
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of the user's webhook.
+func (r *WebhookRepository) GetDeliveries(userID string, subscriptionID string, status string, limit int) ([]*models.WebhookDelivery, error) {
+	var found bool
+	err := r.db.QueryRow(r.ctx,
+		"SELECT EXISTS (SELECT 1 FROM webhook_subscriptions WHERE subscription_id = $1 AND user_id = $2)",
+		subscriptionID,
+		userID,
+	).Scan(&found)
+	if err != nil {
+		return nil, fmt.Errorf("error getting webhook deliveries: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w with id: %s", ErrWebhookNotFound, subscriptionID)
+	}
+	rows, err := r.db.Query(r.ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries d "+
+			"JOIN webhook_subscriptions s ON s.subscription_id = d.subscription_id "+
+			"WHERE d.subscription_id = $1 AND s.user_id = $2 AND ($3 = '' OR d.status = $3) "+
+			"ORDER BY d.created_at DESC LIMIT $4",
+		subscriptionID,
+		userID,
+		status,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting webhook deliveries: %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+func (r *WebhookRepository) GetDeadLetters(userID string, limit int) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.Query(r.ctx,
+		"SELECT "+deliveryColumns+" FROM webhook_deliveries d "+
+			"JOIN webhook_subscriptions s ON s.subscription_id = d.subscription_id "+
+			"WHERE s.user_id = $1 AND d.status = $2 "+
+			"ORDER BY d.created_at DESC LIMIT $3",
+		userID,
+		models.DeliveryDead,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting dead letters: %w", err)
+	}
+	return scanDeliveries(rows)
+}
+
+// Redeliver schedules a dead delivery of the user's webhooks again.
+func (r *WebhookRepository) Redeliver(userID string, deliveryID string) error {
+	res, err := r.db.Exec(r.ctx,
+		"UPDATE webhook_deliveries d SET status = $2, attempts = 0, next_attempt_at = now(), last_error = '' "+
+			"FROM webhook_subscriptions s "+
+			"WHERE d.delivery_id = $1 AND d.status = $3 AND s.subscription_id = d.subscription_id AND s.user_id = $4",
+		deliveryID,
+		models.DeliveryPending,
+		models.DeliveryDead,
+		userID,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error redelivering webhook", zap.Error(err))
+		return fmt.Errorf("error redelivering webhook: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("%w with id: %s", ErrDeliveryNotFound, deliveryID)
+	}
+	return nil
+}
+
+// EnqueueDeliveries creates one delivery per subscription of the event owner
+// that listens to the event type. It is safe to call twice for the same event.
+func (r *WebhookRepository) EnqueueDeliveries(event *models.DomainEvent) error {
+	_, err := r.db.Exec(r.ctx,
+		"INSERT INTO webhook_deliveries (delivery_id, subscription_id, outbox_id, event_type, payload) "+
+			"SELECT gen_random_uuid()::text, subscription_id, $1, $2, $3 FROM webhook_subscriptions "+
+			"WHERE user_id = $4 AND $2 = ANY(event_types) "+
+			"ON CONFLICT (subscription_id, outbox_id) DO NOTHING",
+		event.ID,
+		event.Type,
+		event,
+		event.UserID,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error enqueueing webhook deliveries", zap.Error(err))
+		return fmt.Errorf("error enqueueing webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) ClaimDeliveries(limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.Query(r.ctx,
+		"UPDATE webhook_deliveries d SET status = $3, locked_until = now() + $2 * interval '1 second', attempts = d.attempts + 1 "+
+			"FROM webhook_subscriptions s "+
+			"WHERE s.subscription_id = d.subscription_id AND d.delivery_id IN ("+
+			"SELECT delivery_id FROM webhook_deliveries "+
+			"WHERE (status = $4 AND next_attempt_at <= now()) OR (status = $3 AND locked_until < now()) "+
+			"ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED) "+
+			"RETURNING "+deliveryColumns+", s.url, s.secret",
+		limit,
+		lease.Seconds(),
+		models.DeliveryProcessing,
+		models.DeliveryPending,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error claiming webhook deliveries", zap.Error(err))
+		return nil, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		err := rows.Scan(
+			&d.DeliveryID, &d.SubscriptionID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt,
+			&d.URL, &d.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error claiming webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) MarkDelivered(deliveryID string, responseStatus int) error {
+	_, err := r.db.Exec(r.ctx,
+		"UPDATE webhook_deliveries SET status = $2, locked_until = NULL, response_status = $3, last_error = '', delivered_at = now() "+
+			"WHERE delivery_id = $1",
+		deliveryID,
+		models.DeliveryDelivered,
+		responseStatus,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error marking webhook delivered", zap.Error(err))
+		return fmt.Errorf("error marking webhook delivered: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) RetryDelivery(deliveryID string, nextAttemptAt time.Time, responseStatus int, reason string) error {
+	_, err := r.db.Exec(r.ctx,
+		"UPDATE webhook_deliveries SET status = $2, locked_until = NULL, next_attempt_at = $3, response_status = $4, last_error = $5 "+
+			"WHERE delivery_id = $1",
+		deliveryID,
+		models.DeliveryPending,
+		nextAttemptAt,
+		responseStatus,
+		reason,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error rescheduling webhook", zap.Error(err))
+		return fmt.Errorf("error rescheduling webhook: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) MarkDead(deliveryID string, responseStatus int, reason string) error {
+	_, err := r.db.Exec(r.ctx,
+		"UPDATE webhook_deliveries SET status = $2, locked_until = NULL, response_status = $3, last_error = $4 "+
+			"WHERE delivery_id = $1",
+		deliveryID,
+		models.DeliveryDead,
+		responseStatus,
+		reason,
+	)
+	if err != nil {
+		logger.GetLoggerFromCtx(r.ctx).Error("error moving webhook to dead letters", zap.Error(err))
+		return fmt.Errorf("error moving webhook to dead letters: %w", err)
+	}
+	return nil
+}
+
+func scanDeliveries(rows pgx.Rows) ([]*models.WebhookDelivery, error) {
+	defer rows.Close()
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		err := rows.Scan(
+			&d.DeliveryID, &d.SubscriptionID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, nil
+}
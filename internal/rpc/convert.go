@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"Calendar/internal/models"
+	"Calendar/internal/rpc/calendarpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func eventFromProto(event *calendarpb.Event) *models.Event {
+	if event == nil {
+		return &models.Event{}
+	}
+	result := &models.Event{
+		UserID:       event.GetUserId(),
+		EventID:      event.GetEventId(),
+		Date:         event.GetDate(),
+		StartTime:    event.GetStartTime(),
+		EndTime:      event.GetEndTime(),
+		Event:        event.GetTitle(),
+		Description:  event.GetDescription(),
+		Location:     event.GetLocation(),
+		URL:          event.GetUrl(),
+		Color:        event.GetColor(),
+		Status:       event.GetStatus(),
+		Visibility:   event.GetVisibility(),
+		Transparency: event.GetTransparency(),
+		Tags:         event.GetTags(),
+		Attendees:    event.GetAttendees(),
+		Version:      event.GetVersion(),
+	}
+	for _, reminder := range event.GetReminders() {
+		result.Reminders = append(result.Reminders, &models.Reminder{
+			ReminderID:    reminder.GetReminderId(),
+			EventID:       event.GetEventId(),
+			OffsetMinutes: int(reminder.GetOffsetMinutes()),
+			Channel:       reminder.GetChannel(),
+			Target:        reminder.GetTarget(),
+		})
+	}
+	return result
+}
+
+func eventToProto(event *models.Event) *calendarpb.Event {
+	if event == nil {
+		return nil
+	}
+	result := &calendarpb.Event{
+		UserId:       event.UserID,
+		EventId:      event.EventID,
+		Date:         event.Date,
+		StartTime:    event.StartTime,
+		EndTime:      event.EndTime,
+		Title:        event.Event,
+		Description:  event.Description,
+		Location:     event.Location,
+		Url:          event.URL,
+		Color:        event.Color,
+		Status:       event.Status,
+		Visibility:   event.Visibility,
+		Transparency: event.Transparency,
+		Tags:         event.Tags,
+		Attendees:    event.Attendees,
+		Version:      event.Version,
+	}
+	for _, reminder := range event.Reminders {
+		result.Reminders = append(result.Reminders, &calendarpb.Reminder{
+			ReminderId:    reminder.ReminderID,
+			OffsetMinutes: int32(reminder.OffsetMinutes),
+			Channel:       reminder.Channel,
+			Target:        reminder.Target,
+		})
+	}
+	return result
+}
+
+func changeToProto(change *models.DomainEvent) *calendarpb.Change {
+	return &calendarpb.Change{
+		Id:         change.Seq,
+		Type:       change.Type,
+		EventId:    change.EventID,
+		UserId:     change.UserID,
+		Before:     eventToProto(change.Before),
+		After:      eventToProto(change.After),
+		OccurredAt: timestamppb.New(change.OccurredAt),
+	}
+}
@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"Calendar/internal/errors"
+	errors1 "errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+)
+
+// statusError maps the service errors onto gRPC status codes the way
+// handleError maps them onto HTTP statuses.
+func statusError(err error) error {
+	var validationErr *errors.ValidationError
+	var notFoundErr *errors.NotFoundError
+	var preconditionErr *errors.PreconditionFailedError
+	var requiredErr *errors.PreconditionRequiredError
+	var quotaErr *errors.QuotaExceededError
+	var conflictErr *errors.ConflictError
+	var abortedErr *errors.AbortedError
+	var businessErr *errors.BusinessError
+
+	switch {
+	case errors1.As(err, &validationErr):
+		st, detailErr := status.New(codes.InvalidArgument, validationErr.Error()).WithDetails(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: validationErr.Field, Description: validationErr.Message}},
+		})
+		if detailErr != nil {
+			return status.Error(codes.InvalidArgument, validationErr.Error())
+		}
+		return st.Err()
+	case errors1.As(err, &notFoundErr):
+		return status.Error(codes.NotFound, notFoundErr.Error())
+	case errors1.As(err, &preconditionErr):
+		st, detailErr := status.New(codes.Aborted, preconditionErr.Error()).WithDetails(&errdetails.ErrorInfo{
+			Reason:   "VERSION_MISMATCH",
+			Metadata: map[string]string{"version": strconv.FormatInt(preconditionErr.Version, 10)},
+		})
+		if detailErr != nil {
+			return status.Error(codes.Aborted, preconditionErr.Error())
+		}
+		return st.Err()
+	case errors1.As(err, &requiredErr):
+		return status.Error(codes.FailedPrecondition, requiredErr.Error())
+	case errors1.As(err, &quotaErr):
+		st, detailErr := status.New(codes.ResourceExhausted, quotaErr.Error()).WithDetails(&errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{Subject: quotaErr.Resource, Description: quotaErr.Error()}},
+		})
+		if detailErr != nil {
+			return status.Error(codes.ResourceExhausted, quotaErr.Error())
+		}
+		return st.Err()
+	case errors1.As(err, &conflictErr):
+		return status.Error(codes.AlreadyExists, conflictErr.Error())
+	case errors1.As(err, &abortedErr):
+		return status.Error(codes.Aborted, abortedErr.Error())
+	case errors1.As(err, &businessErr):
+		return status.Error(codes.Unavailable, businessErr.Error())
+	default:
+		return status.Error(codes.Internal, "An unexpected error occurred")
+	}
+}
@@ -0,0 +1,209 @@
+package rpc
+
+//go:generate protoc -I ../../proto --go_out=calendarpb --go_opt=paths=source_relative --go-grpc_out=calendarpb --go-grpc_opt=paths=source_relative calendar/v1/calendar.proto
+
+import (
+	"Calendar/internal/config"
+	"Calendar/internal/models"
+	"Calendar/internal/rpc/calendarpb"
+	"Calendar/internal/service"
+	"Calendar/pkg/logger"
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net"
+	"strconv"
+	"sync"
+)
+
+const watchBuffer = 256
+
+// CalendarServer serves the gRPC API. It wraps the same services as the HTTP
+// server.
+type CalendarServer struct {
+	calendarpb.UnimplementedCalendarServiceServer
+	srv        service.CalendarServiceInterface
+	changes    service.ChangeServiceInterface
+	cfg        *config.Config
+	ctx        context.Context
+	grpcServer *grpc.Server
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+func NewCalendarServer(ctx context.Context, cfg *config.Config, srv service.CalendarServiceInterface, changes service.ChangeServiceInterface) *CalendarServer {
+	s := &CalendarServer{
+		ctx:     ctx,
+		cfg:     cfg,
+		srv:     srv,
+		changes: changes,
+		done:    make(chan struct{}),
+	}
+	s.grpcServer = grpc.NewServer(grpc.ChainUnaryInterceptor(s.logUnary), grpc.ChainStreamInterceptor(s.logStream))
+	calendarpb.RegisterCalendarServiceServer(s.grpcServer, s)
+	return s
+}
+
+func (s *CalendarServer) Run() error {
+	lis, err := net.Listen("tcp", s.cfg.Host+":"+s.cfg.GRPCPort)
+	if err != nil {
+		return err
+	}
+	return s.Serve(lis)
+}
+
+// Serve accepts connections on lis until Shutdown.
+func (s *CalendarServer) Serve(lis net.Listener) error {
+	logger.GetLoggerFromCtx(s.ctx).Info("grpc server is running", zap.String("address", lis.Addr().String()))
+	if err := s.grpcServer.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown ends open watches and waits for in-flight calls. Calls still
+// running when ctx is done are cancelled.
+func (s *CalendarServer) Shutdown(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.done) })
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
+
+// actingService returns the service recording the user named by the user-id
+// metadata as the author of its changes, like the user_id query parameter over
+// HTTP.
+func (s *CalendarServer) actingService(ctx context.Context) service.CalendarServiceInterface {
+	var actorID string
+	if values := metadata.ValueFromIncomingContext(ctx, "user-id"); len(values) > 0 {
+		actorID = values[0]
+	}
+	return service.WithActor(s.srv, actorID)
+}
+
+func (s *CalendarServer) CreateEvent(ctx context.Context, req *calendarpb.CreateEventRequest) (*calendarpb.Event, error) {
+	event := eventFromProto(req.GetEvent())
+	if _, err := s.actingService(ctx).CreateEvent(event); err != nil {
+		return nil, statusError(err)
+	}
+	return eventToProto(event), nil
+}
+
+func (s *CalendarServer) GetEvent(ctx context.Context, req *calendarpb.GetEventRequest) (*calendarpb.Event, error) {
+	event, err := s.srv.GetEvent(req.GetEventId(), req.GetUserId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return eventToProto(event), nil
+}
+
+func (s *CalendarServer) UpdateEvent(ctx context.Context, req *calendarpb.UpdateEventRequest) (*calendarpb.Event, error) {
+	event := eventFromProto(req.GetEvent())
+	if err := s.actingService(ctx).UpdateEvent(event); err != nil {
+		return nil, statusError(err)
+	}
+	return eventToProto(event), nil
+}
+
+func (s *CalendarServer) DeleteEvent(ctx context.Context, req *calendarpb.DeleteEventRequest) (*calendarpb.DeleteEventResponse, error) {
+	if err := s.actingService(ctx).DeleteEvent(req.GetEventId(), req.GetVersion()); err != nil {
+		return nil, statusError(err)
+	}
+	return &calendarpb.DeleteEventResponse{}, nil
+}
+
+func (s *CalendarServer) ListEvents(ctx context.Context, req *calendarpb.ListEventsRequest) (*calendarpb.ListEventsResponse, error) {
+	params := &models.EventQueryParams{
+		ViewerID:    req.GetUserId(),
+		CalendarIDs: req.GetCalendarIds(),
+		From:        req.GetFrom(),
+		To:          req.GetTo(),
+		Text:        req.GetQuery(),
+		Statuses:    req.GetStatuses(),
+		Tags:        req.GetTags(),
+		Sort:        req.GetSort(),
+		Cursor:      req.GetPageToken(),
+	}
+	if req.GetPageSize() != 0 {
+		params.Limit = strconv.Itoa(int(req.GetPageSize()))
+	}
+	page, err := s.srv.QueryEvents(params)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := &calendarpb.ListEventsResponse{NextPageToken: page.NextCursor}
+	for _, event := range page.Events {
+		resp.Events = append(resp.Events, eventToProto(event))
+	}
+	return resp, nil
+}
+
+// WatchEvents works like the SSE stream: the changes the client missed come
+// first, then new ones.
+func (s *CalendarServer) WatchEvents(req *calendarpb.WatchEventsRequest, stream grpc.ServerStreamingServer[calendarpb.Change]) error {
+	userID := req.GetUserId()
+	// Subscribe before reading the change log so that a change published
+	// in between is not lost; duplicates are skipped by seq below.
+	sub := s.changes.Subscribe("grpc", watchBuffer, func(event *models.DomainEvent) bool {
+		return event.UserID == userID
+	})
+	defer sub.Close()
+	var lastEventID string
+	if req.GetAfterId() > 0 {
+		lastEventID = strconv.FormatInt(req.GetAfterId(), 10)
+	}
+	changes, err := s.changes.GetChangesSince(userID, lastEventID)
+	if err != nil {
+		return statusError(err)
+	}
+	sent := req.GetAfterId()
+	for _, change := range changes {
+		if err := stream.Send(changeToProto(change)); err != nil {
+			return err
+		}
+		sent = change.Seq
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-s.done:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-sub.Lagged:
+			return status.Error(codes.Unavailable, "client fell behind, resume with the last change id")
+		case change := <-sub.C:
+			if change.Seq <= sent {
+				continue
+			}
+			if err := stream.Send(changeToProto(change)); err != nil {
+				return err
+			}
+			sent = change.Seq
+		}
+	}
+}
+
+func (s *CalendarServer) logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	logger.GetLoggerFromCtx(s.ctx).Info("grpc call", zap.String("method", info.FullMethod), zap.Stringer("code", status.Code(err)))
+	return resp, err
+}
+
+func (s *CalendarServer) logStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := handler(srv, stream)
+	logger.GetLoggerFromCtx(s.ctx).Info("grpc stream", zap.String("method", info.FullMethod), zap.Stringer("code", status.Code(err)))
+	return err
+}
@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"Calendar/pkg/logger"
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+type PurgeConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"POLL_INTERVAL" env-default:"1h"`
+	BatchSize    int           `yaml:"batch_size" env:"BATCH_SIZE" env-default:"1000"`
+}
+
+// Purger periodically deletes rows that are no longer needed. purge deletes
+// up to limit rows and returns how many it deleted; it is called until it
+// deletes less than a batch.
+type Purger struct {
+	ctx   context.Context
+	name  string
+	cfg   PurgeConfig
+	purge func(limit int) (int64, error)
+}
+
+func NewPurger(ctx context.Context, name string, cfg PurgeConfig, purge func(limit int) (int64, error)) *Purger {
+	return &Purger{
+		ctx:   ctx,
+		name:  name,
+		cfg:   cfg,
+		purge: purge,
+	}
+}
+
+func (p *Purger) Run() {
+	logger.GetLoggerFromCtx(p.ctx).Info("purger started", zap.String("name", p.name), zap.Duration("poll_interval", p.cfg.PollInterval))
+	ticker := time.NewTicker(p.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		p.purgeAll()
+		select {
+		case <-p.ctx.Done():
+			logger.GetLoggerFromCtx(p.ctx).Info("purger stopped", zap.String("name", p.name))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) purgeAll() {
+	var total int64
+	for {
+		n, err := p.purge(p.cfg.BatchSize)
+		if err != nil {
+			logger.GetLoggerFromCtx(p.ctx).Error("error purging", zap.String("name", p.name), zap.Error(err))
+			break
+		}
+		total += n
+		if n < int64(p.cfg.BatchSize) || p.ctx.Err() != nil {
+			break
+		}
+	}
+	if total > 0 {
+		logger.GetLoggerFromCtx(p.ctx).Info("purged", zap.String("name", p.name), zap.Int64("count", total))
+	}
+}
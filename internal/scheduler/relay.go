@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"Calendar/internal/eventbus"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/pkg/logger"
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+type RelayConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" env-default:"1s"`
+	BatchSize    int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+}
+
+// OutboxRelay publishes committed domain events from the outbox to the event
+// bus, the changes of every calendar in commit order. An event is marked
+// processed only after every subscriber handled it, so a crash leads to a
+// redelivery rather than a loss.
+type OutboxRelay struct {
+	ctx  context.Context
+	cfg  RelayConfig
+	repo repository.OutboxRepositoryInterface
+	bus  eventbus.EventBus
+}
+
+func NewOutboxRelay(ctx context.Context, cfg RelayConfig, repo repository.OutboxRepositoryInterface, bus eventbus.EventBus) *OutboxRelay {
+	return &OutboxRelay{
+		ctx:  ctx,
+		cfg:  cfg,
+		repo: repo,
+		bus:  bus,
+	}
+}
+
+func (r *OutboxRelay) Run() {
+	logger.GetLoggerFromCtx(r.ctx).Info("outbox relay started", zap.Duration("poll_interval", r.cfg.PollInterval))
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		r.relay()
+		select {
+		case <-r.ctx.Done():
+			logger.GetLoggerFromCtx(r.ctx).Info("outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *OutboxRelay) relay() {
+	ctx := context.WithoutCancel(r.ctx)
+	for {
+		n, err := r.repo.ProcessOutbox(r.cfg.BatchSize, func(event *models.DomainEvent) error {
+			return r.bus.Publish(ctx, event)
+		})
+		if err != nil {
+			logger.GetLoggerFromCtx(r.ctx).Error("error relaying outbox", zap.Error(err))
+			return
+		}
+		if n < r.cfg.BatchSize || r.ctx.Err() != nil {
+			return
+		}
+	}
+}
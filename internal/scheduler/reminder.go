@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"Calendar/internal/models"
+	"Calendar/internal/notifier"
+	"Calendar/internal/repository"
+	"Calendar/pkg/logger"
+	"context"
+	"go.uber.org/zap"
+	"time"
+)
+
+type Config struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"SCHEDULER_POLL_INTERVAL" env-default:"5s"`
+	BatchSize    int           `yaml:"batch_size" env:"SCHEDULER_BATCH_SIZE" env-default:"100"`
+	Lease        time.Duration `yaml:"lease" env:"SCHEDULER_LEASE" env-default:"1m"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"SCHEDULER_MAX_ATTEMPTS" env-default:"5"`
+	RetryDelay   time.Duration `yaml:"retry_delay" env:"SCHEDULER_RETRY_DELAY" env-default:"30s"`
+	StopTimeout  time.Duration `yaml:"stop_timeout" env:"SCHEDULER_STOP_TIMEOUT" env-default:"10s"`
+}
+
+// ReminderWorker polls scheduled_jobs for due reminders and hands them to the
+// notifier. A job is marked done only after a successful delivery, so a
+// reminder is delivered at least once: if completing the job fails, it is sent
+// again after the lease expires, with the same delivery ID.
+type ReminderWorker struct {
+	ctx      context.Context
+	cfg      Config
+	repo     repository.ReminderRepositoryInterface
+	notifier notifier.Notifier
+}
+
+func NewReminderWorker(ctx context.Context, cfg Config, repo repository.ReminderRepositoryInterface, n notifier.Notifier) *ReminderWorker {
+	return &ReminderWorker{
+		ctx:      ctx,
+		cfg:      cfg,
+		repo:     repo,
+		notifier: n,
+	}
+}
+
+// Run blocks until the worker context is cancelled. The batch in flight is
+// finished for at most StopTimeout so that claimed jobs are not left locked
+// until their lease expires; jobs not started by then wait for the lease.
+func (w *ReminderWorker) Run() {
+	logger.GetLoggerFromCtx(w.ctx).Info("reminder worker started", zap.Duration("poll_interval", w.cfg.PollInterval))
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		w.processDue()
+		select {
+		case <-w.ctx.Done():
+			logger.GetLoggerFromCtx(w.ctx).Info("reminder worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *ReminderWorker) processDue() {
+	jobs, err := w.repo.ClaimDueJobs(w.cfg.BatchSize, w.cfg.Lease)
+	if err != nil {
+		logger.GetLoggerFromCtx(w.ctx).Error("error claiming reminder jobs", zap.Error(err))
+		return
+	}
+	ctx, cancel := drainContext(w.ctx, w.cfg.StopTimeout)
+	defer cancel()
+	for i, job := range jobs {
+		if ctx.Err() != nil {
+			logger.GetLoggerFromCtx(w.ctx).Warn("reminder worker stopped before the end of the batch", zap.Int("undelivered", len(jobs)-i))
+			return
+		}
+		w.deliver(ctx, job)
+	}
+}
+
+// drainContext returns a context that is not cancelled with ctx but at most
+// timeout after it.
+func drainContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	drain, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancel()
+		case <-drain.Done():
+		}
+	})
+	return drain, func() {
+		stop()
+		cancel()
+	}
+}
+
+func (w *ReminderWorker) deliver(ctx context.Context, job *models.ReminderJob) {
+	n := &models.Notification{
+		DeliveryID: job.JobID,
+		ReminderID: job.Reminder.ReminderID,
+		EventID:    job.Event.EventID,
+		UserID:     job.Event.UserID,
+		Event:      job.Event.Event,
+		Date:       job.Event.Date,
+		StartTime:  job.Event.StartTime,
+		Channel:    job.Reminder.Channel,
+		Target:     job.Reminder.Target,
+		FireAt:     job.RunAt,
+	}
+	err := w.notifier.Notify(ctx, n)
+	if err == nil {
+		if err := w.repo.CompleteJob(job.JobID); err != nil {
+			logger.GetLoggerFromCtx(w.ctx).Error("error completing reminder job", zap.String("job_id", job.JobID), zap.Error(err))
+		}
+		return
+	}
+	logger.GetLoggerFromCtx(w.ctx).Warn("error delivering reminder", zap.String("job_id", job.JobID), zap.Int("attempt", job.Attempts), zap.Error(err))
+	if job.Attempts >= w.cfg.MaxAttempts {
+		err = w.repo.FailJob(job.JobID, err.Error())
+	} else {
+		err = w.repo.RetryJob(job.JobID, time.Now().Add(w.cfg.RetryDelay*time.Duration(job.Attempts)), err.Error())
+	}
+	if err != nil {
+		logger.GetLoggerFromCtx(w.ctx).Error("error rescheduling reminder job", zap.String("job_id", job.JobID), zap.Error(err))
+	}
+}
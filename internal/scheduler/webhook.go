@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"Calendar/internal/models"
+	"Calendar/internal/notifier"
+	"Calendar/internal/repository"
+	"Calendar/pkg/logger"
+	"bytes"
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type WebhookConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL" env-default:"2s"`
+	BatchSize    int           `yaml:"batch_size" env:"WEBHOOK_BATCH_SIZE" env-default:"100"`
+	Lease        time.Duration `yaml:"lease" env:"WEBHOOK_LEASE" env-default:"1m"`
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOK_TIMEOUT" env-default:"10s"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" env-default:"8"`
+	BaseBackoff  time.Duration `yaml:"base_backoff" env:"WEBHOOK_BASE_BACKOFF" env-default:"10s"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" env:"WEBHOOK_MAX_BACKOFF" env-default:"1h"`
+	StopTimeout  time.Duration `yaml:"stop_timeout" env:"WEBHOOK_STOP_TIMEOUT" env-default:"10s"`
+}
+
+// WebhookWorker turns domain events from the bus into per-subscription
+// deliveries and posts them to subscribers. Failed deliveries are retried with
+// exponential backoff and end up in the dead letters after MaxAttempts.
+type WebhookWorker struct {
+	ctx    context.Context
+	cfg    WebhookConfig
+	repo   repository.WebhookRepositoryInterface
+	client *http.Client
+}
+
+func NewWebhookWorker(ctx context.Context, cfg WebhookConfig, repo repository.WebhookRepositoryInterface) *WebhookWorker {
+	return &WebhookWorker{
+		ctx:    ctx,
+		cfg:    cfg,
+		repo:   repo,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Run blocks until the worker context is cancelled. The batch in flight is
+// finished for at most StopTimeout; deliveries not started by then wait for
+// their lease.
+func (w *WebhookWorker) Run() {
+	logger.GetLoggerFromCtx(w.ctx).Info("webhook worker started", zap.Duration("poll_interval", w.cfg.PollInterval))
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		w.processDue()
+		select {
+		case <-w.ctx.Done():
+			logger.GetLoggerFromCtx(w.ctx).Info("webhook worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enqueue is the event bus handler that schedules deliveries for an event.
+func (w *WebhookWorker) Enqueue(ctx context.Context, event *models.DomainEvent) error {
+	return w.repo.EnqueueDeliveries(event)
+}
+
+func (w *WebhookWorker) processDue() {
+	deliveries, err := w.repo.ClaimDeliveries(w.cfg.BatchSize, w.cfg.Lease)
+	if err != nil {
+		logger.GetLoggerFromCtx(w.ctx).Error("error claiming webhook deliveries", zap.Error(err))
+		return
+	}
+	ctx, cancel := drainContext(w.ctx, w.cfg.StopTimeout)
+	defer cancel()
+	for i, delivery := range deliveries {
+		if ctx.Err() != nil {
+			logger.GetLoggerFromCtx(w.ctx).Warn("webhook worker stopped before the end of the batch", zap.Int("undelivered", len(deliveries)-i))
+			return
+		}
+		w.deliver(ctx, delivery)
+	}
+}
+
+func (w *WebhookWorker) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	status, err := w.post(ctx, delivery)
+	if err == nil {
+		err = w.repo.MarkDelivered(delivery.DeliveryID, status)
+		if err != nil {
+			logger.GetLoggerFromCtx(w.ctx).Error("error marking webhook delivered", zap.String("delivery_id", delivery.DeliveryID), zap.Error(err))
+		}
+		return
+	}
+	logger.GetLoggerFromCtx(w.ctx).Warn("error delivering webhook",
+		zap.String("delivery_id", delivery.DeliveryID),
+		zap.Int("attempt", delivery.Attempts),
+		zap.Error(err),
+	)
+	if delivery.Attempts >= w.cfg.MaxAttempts {
+		err = w.repo.MarkDead(delivery.DeliveryID, status, err.Error())
+	} else {
+		err = w.repo.RetryDelivery(delivery.DeliveryID, time.Now().Add(Backoff(w.cfg.BaseBackoff, w.cfg.MaxBackoff, delivery.Attempts)), status, err.Error())
+	}
+	if err != nil {
+		logger.GetLoggerFromCtx(w.ctx).Error("error rescheduling webhook", zap.String("delivery_id", delivery.DeliveryID), zap.Error(err))
+	}
+}
+
+func (w *WebhookWorker) post(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("error creating webhook request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Calendar-Event", delivery.EventType)
+	req.Header.Set("X-Calendar-Delivery", delivery.DeliveryID)
+	req.Header.Set(notifier.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(notifier.SignatureHeader, notifier.Sign(delivery.Secret, timestamp, delivery.Payload))
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error sending webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff returns base * 2^(attempt-1) capped at max.
+func Backoff(base, max time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
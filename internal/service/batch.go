@@ -0,0 +1,160 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	errors1 "errors"
+	"github.com/google/uuid"
+)
+
+// maxBatchOperations limits the operations of one batch.
+const maxBatchOperations = 100
+
+// ApplyBatch validates and applies the operations in order and returns one
+// result per operation. In atomic mode, the default, the batch stops at the
+// first failing operation and none of the others are stored; they fail with
+// an AbortedError. In best effort mode every operation succeeds or fails on
+// its own.
+func (s *CalendarService) ApplyBatch(batch *models.Batch) ([]*models.BatchResult, error) {
+	if batch.Mode == "" {
+		batch.Mode = models.BatchAtomic
+	}
+	if batch.Mode != models.BatchAtomic && batch.Mode != models.BatchBestEffort {
+		return nil, &errors.ValidationError{
+			Field:   "mode",
+			Message: "must be one of atomic, best_effort",
+		}
+	}
+	if len(batch.Operations) == 0 {
+		return nil, &errors.ValidationError{
+			Field:   "operations",
+			Message: "can't be empty",
+		}
+	}
+	if len(batch.Operations) > maxBatchOperations {
+		return nil, &errors.ValidationError{
+			Field:   "operations",
+			Message: "can't have more than 100 entries",
+		}
+	}
+	for _, op := range batch.Operations {
+		if op == nil {
+			return nil, &errors.ValidationError{
+				Field:   "operations",
+				Message: "can't contain null",
+			}
+		}
+	}
+
+	results := make([]*models.BatchResult, len(batch.Operations))
+	if batch.Mode == models.BatchBestEffort {
+		for i, op := range batch.Operations {
+			err := prepareOperation(op)
+			if err == nil {
+				err = s.checkOperationQuota(op, make(map[string]int))
+			}
+			if err == nil {
+				err = s.storeOperation(op)
+			}
+			results[i] = operationResult(op, err)
+		}
+		return results, nil
+	}
+
+	failed := -1
+	added := make(map[string]int)
+	for i, op := range batch.Operations {
+		err := prepareOperation(op)
+		if err == nil {
+			err = s.checkOperationQuota(op, added)
+		}
+		if err != nil {
+			results[i] = operationResult(op, err)
+			failed = i
+			break
+		}
+	}
+	if failed < 0 {
+		err := s.repo.ApplyBatch(batch.Operations)
+		var batchErr *repository.BatchError
+		if errors1.As(err, &batchErr) {
+			failed = batchErr.Index
+			op := batch.Operations[failed]
+			results[failed] = operationResult(op, repositoryError(batchErr.Err, operationEventID(op)))
+		} else if err != nil {
+			return nil, &errors.BusinessError{
+				Message: err.Error(),
+			}
+		}
+	}
+	for i, op := range batch.Operations {
+		switch {
+		case failed < 0:
+			results[i] = operationResult(op, nil)
+		case i != failed:
+			results[i] = operationResult(op, &errors.AbortedError{Index: failed})
+		}
+	}
+	return results, nil
+}
+
+// prepareOperation validates the operation like the matching single event
+// call and assigns the ID of a new event.
+func prepareOperation(op *models.BatchOperation) error {
+	switch op.Op {
+	case models.BatchCreate, models.BatchUpdate:
+		if op.Event == nil {
+			return &errors.ValidationError{
+				Field:   "event",
+				Message: "can't be empty",
+			}
+		}
+		if op.Op == models.BatchUpdate {
+			return validateChangedEvent(op.Event)
+		}
+		if err := validateNewEvent(op.Event); err != nil {
+			return err
+		}
+		op.Event.EventID = uuid.New().String()
+		return nil
+	case models.BatchDelete:
+		return validateDeletion(op.EventID, op.Version)
+	default:
+		return &errors.ValidationError{
+			Field:   "op",
+			Message: "must be one of create, update, delete",
+		}
+	}
+}
+
+// storeOperation applies a prepared operation on its own.
+func (s *CalendarService) storeOperation(op *models.BatchOperation) error {
+	var err error
+	switch op.Op {
+	case models.BatchCreate:
+		err = s.repo.CreateEvent(op.Event)
+	case models.BatchUpdate:
+		err = s.repo.UpdateEvent(op.Event)
+	case models.BatchDelete:
+		err = s.repo.DeleteEvent(op.EventID, op.Version)
+	}
+	if err != nil {
+		return repositoryError(err, operationEventID(op))
+	}
+	return nil
+}
+
+func operationResult(op *models.BatchOperation, err error) *models.BatchResult {
+	if err != nil || op.Op == models.BatchDelete {
+		return &models.BatchResult{Err: err}
+	}
+	return &models.BatchResult{Event: op.Event}
+}
+
+func operationEventID(op *models.BatchOperation) string {
+	if op.Event != nil {
+		return op.Event.EventID
+	}
+	return op.EventID
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/eventbus"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"context"
+	"strconv"
+)
+
+const changesPageSize = 500
+
+type ChangeServiceInterface interface {
+	GetChangesSince(userID string, lastEventID string) ([]*models.DomainEvent, error)
+	Subscribe(name string, buffer int, filter func(event *models.DomainEvent) bool) *eventbus.Subscription
+}
+
+type ChangeService struct {
+	repo repository.OutboxRepositoryInterface
+	bus  eventbus.EventBus
+	ctx  context.Context
+}
+
+func NewChangeService(ctx context.Context, repo repository.OutboxRepositoryInterface, bus eventbus.EventBus) *ChangeService {
+	return &ChangeService{
+		ctx:  ctx,
+		repo: repo,
+		bus:  bus,
+	}
+}
+
+// GetChangesSince returns every change of the user's calendar after the seq
+// lastEventID. An empty lastEventID means the client has nothing to resume.
+func (s *ChangeService) GetChangesSince(userID string, lastEventID string) ([]*models.DomainEvent, error) {
+	if userID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	if lastEventID == "" {
+		return []*models.DomainEvent{}, nil
+	}
+	afterSeq, err := strconv.ParseInt(lastEventID, 10, 64)
+	if err != nil || afterSeq < 0 {
+		return nil, &errors.ValidationError{
+			Field:   "last_event_id",
+			Message: "must be a non-negative integer",
+		}
+	}
+	changes := []*models.DomainEvent{}
+	for {
+		page, err := s.repo.GetChangesSince(userID, afterSeq, changesPageSize)
+		if err != nil {
+			return nil, &errors.BusinessError{
+				Message: err.Error(),
+			}
+		}
+		changes = append(changes, page...)
+		if len(page) < changesPageSize {
+			return changes, nil
+		}
+		afterSeq = page[len(page)-1].Seq
+	}
+}
+
+func (s *ChangeService) Subscribe(name string, buffer int, filter func(event *models.DomainEvent) bool) *eventbus.Subscription {
+	return eventbus.NewSubscription(s.bus, name, buffer, filter)
+}
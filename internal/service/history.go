@@ -0,0 +1,189 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 500
+)
+
+// WithActor returns a service that records actorID as the author of the
+// changes it makes.
+func (s *CalendarService) WithActor(actorID string) CalendarServiceInterface {
+	scoped := *s
+	scoped.repo = repository.WithActor(s.repo, actorID)
+	scoped.actor = actorID
+	return &scoped
+}
+
+// WithActor returns srv attributing its changes to actorID, or srv itself if
+// it doesn't record who made a change.
+func WithActor(srv CalendarServiceInterface, actorID string) CalendarServiceInterface {
+	if scoped, ok := srv.(interface {
+		WithActor(actorID string) CalendarServiceInterface
+	}); ok {
+		return scoped.WithActor(actorID)
+	}
+	return srv
+}
+
+// GetHistory returns every revision of the event, the oldest first. Only the
+// current owner of the event may read its history; it includes the trashed
+// and purged states.
+func (s *CalendarService) GetHistory(eventID string, viewerID string) ([]*models.HistoryEntry, error) {
+	if eventID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "event_id",
+			Message: "event id can't be empty",
+		}
+	}
+	if viewerID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	entries, err := s.repo.GetHistory(eventID)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	if len(entries) == 0 || entries[len(entries)-1].UserID != viewerID {
+		return nil, &errors.NotFoundError{Resource: "event", ID: eventID}
+	}
+	return entries, nil
+}
+
+// Audit returns one page of the changes made to all events, the newest first.
+func (s *CalendarService) Audit(params *models.AuditParams) (*models.AuditPage, error) {
+	query, err := parseAuditQuery(params)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.Audit(query)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	page := &models.AuditPage{Entries: []*models.HistoryEntry{}}
+	if len(entries) > query.Limit-1 {
+		entries = entries[:query.Limit-1]
+		page.NextCursor = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+	page.Entries = append(page.Entries, entries...)
+	return page, nil
+}
+
+// RevertEvent restores the event to the state it had at the given revision
+// if its current version is version, and returns it with its new version.
+// Only the current owner of the event may revert it. Trashed events must be
+// restored first.
+func (s *CalendarService) RevertEvent(eventID string, userID string, revision int, version int64) (*models.Event, error) {
+	if eventID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "event_id",
+			Message: "event id can't be empty",
+		}
+	}
+	if userID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	if revision <= 0 {
+		return nil, &errors.ValidationError{
+			Field:   "revision",
+			Message: "must be a positive number",
+		}
+	}
+	entries, err := s.repo.GetHistory(eventID)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	if len(entries) == 0 || entries[len(entries)-1].UserID != userID {
+		return nil, &errors.NotFoundError{Resource: "event", ID: eventID}
+	}
+	var target *models.HistoryEntry
+	for _, entry := range entries {
+		if entry.Revision == revision {
+			target = entry
+		}
+	}
+	if target == nil {
+		return nil, &errors.NotFoundError{Resource: "revision", ID: strconv.Itoa(revision)}
+	}
+	event := *target.Snapshot
+	event.EventID = eventID
+	event.Version = version
+	event.DeletedAt = nil
+	if err := validateChangedEvent(&event); err != nil {
+		return nil, err
+	}
+	if err := s.checkMoveQuota(&event); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RevertEvent(&event, revision); err != nil {
+		return nil, repositoryError(err, eventID)
+	}
+	return &event, nil
+}
+
+func parseAuditQuery(params *models.AuditParams) (*models.AuditQuery, error) {
+	query := &models.AuditQuery{Actor: params.Actor, UserID: params.UserID}
+	var err error
+	if params.From != "" {
+		if query.From, err = time.Parse(time.RFC3339, params.From); err != nil {
+			return nil, &errors.ValidationError{
+				Field:   "from",
+				Message: "format must be RFC 3339",
+			}
+		}
+	}
+	if params.To != "" {
+		if query.To, err = time.Parse(time.RFC3339, params.To); err != nil {
+			return nil, &errors.ValidationError{
+				Field:   "to",
+				Message: "format must be RFC 3339",
+			}
+		}
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		return nil, &errors.ValidationError{
+			Field:   "to",
+			Message: "can't be before from",
+		}
+	}
+	if params.Cursor != "" {
+		query.BeforeID, err = strconv.ParseInt(params.Cursor, 10, 64)
+		if err != nil || query.BeforeID <= 0 {
+			return nil, &errors.ValidationError{
+				Field:   "cursor",
+				Message: "invalid cursor",
+			}
+		}
+	}
+	limit := defaultAuditLimit
+	if params.Limit != "" {
+		limit, err = strconv.Atoi(params.Limit)
+		if err != nil || limit <= 0 || limit > maxAuditLimit {
+			return nil, &errors.ValidationError{
+				Field:   "limit",
+				Message: "must be a number between 1 and 500",
+			}
+		}
+	}
+	// One extra row tells whether there is a next page.
+	query.Limit = limit + 1
+	return query, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"context"
+	"time"
+	"unicode/utf8"
+)
+
+const maxIdempotencyKeyLength = 255
+
+type IdempotencyConfig struct {
+	TTL   time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+	Lease time.Duration `yaml:"lease" env:"IDEMPOTENCY_LEASE" env-default:"1m"`
+}
+
+type IdempotencyServiceInterface interface {
+	Begin(scope string, key string, fingerprint string) (*models.IdempotencyRecord, error)
+	Complete(record *models.IdempotencyRecord) error
+	Abandon(scope string, key string) error
+}
+
+type IdempotencyService struct {
+	repo repository.IdempotencyRepositoryInterface
+	cfg  IdempotencyConfig
+	ctx  context.Context
+}
+
+func NewIdempotencyService(ctx context.Context, cfg IdempotencyConfig, repo repository.IdempotencyRepositoryInterface) *IdempotencyService {
+	return &IdempotencyService{
+		ctx:  ctx,
+		cfg:  cfg,
+		repo: repo,
+	}
+}
+
+// Begin claims the key of the caller identified by scope for a request with
+// the given fingerprint. It returns nil if the request should run, or the
+// stored response of the first request with the key. Reusing a key for another
+// request, or while the first one is still running, is a conflict.
+func (s *IdempotencyService) Begin(scope string, key string, fingerprint string) (*models.IdempotencyRecord, error) {
+	if utf8.RuneCountInString(key) > maxIdempotencyKeyLength {
+		return nil, &errors.ValidationError{
+			Field:   "Idempotency-Key",
+			Message: "must be at most 255 characters",
+		}
+	}
+	stored, err := s.repo.Claim(&models.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(s.cfg.TTL),
+	}, s.cfg.Lease)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	if stored == nil {
+		return nil, nil
+	}
+	if stored.Status == 0 {
+		return nil, &errors.ConflictError{Message: "a request with this Idempotency-Key is still in progress"}
+	}
+	if stored.Fingerprint != fingerprint {
+		return nil, &errors.ConflictError{Message: "Idempotency-Key was already used for a different request"}
+	}
+	return stored, nil
+}
+
+// Complete stores the response to replay for the key.
+func (s *IdempotencyService) Complete(record *models.IdempotencyRecord) error {
+	if err := s.repo.Save(record); err != nil {
+		return &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+// Abandon frees the key of a request that failed on the server side, so that
+// the client can retry it.
+func (s *IdempotencyService) Abandon(scope string, key string) error {
+	if err := s.repo.Release(scope, key); err != nil {
+		return &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
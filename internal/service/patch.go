@@ -0,0 +1,102 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"encoding/json"
+	errors1 "errors"
+)
+
+// PatchEvent applies an RFC 7396 JSON merge patch to the event and stores the
+// result: fields missing from the patch keep their value and fields set to
+// null are cleared. The merged event is validated like a new one. The version
+// is taken from the patch if the caller passes zero.
+func (s *CalendarService) PatchEvent(eventID string, patch []byte, version int64) (*models.Event, error) {
+	if eventID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "event_id",
+			Message: "event id can't be empty",
+		}
+	}
+	var patchDoc map[string]any
+	if err := json.Unmarshal(patch, &patchDoc); err != nil || patchDoc == nil {
+		return nil, &errors.ValidationError{
+			Field:   "request_body",
+			Message: "must be a JSON object",
+		}
+	}
+	if version == 0 {
+		if v, ok := patchDoc["version"].(float64); ok {
+			version = int64(v)
+		}
+	}
+	if version <= 0 {
+		return nil, &errors.PreconditionRequiredError{Resource: "event", ID: eventID}
+	}
+	if id, ok := patchDoc["event_id"]; ok && id != eventID {
+		return nil, &errors.ValidationError{
+			Field:   "event_id",
+			Message: "can't be changed",
+		}
+	}
+
+	current, err := s.repo.GetEvent(eventID)
+	if err != nil {
+		return nil, repositoryError(err, eventID)
+	}
+	if current.Version != version {
+		return nil, &errors.PreconditionFailedError{Resource: "event", ID: eventID, Version: current.Version}
+	}
+	data, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	merged, err := json.Marshal(mergePatch(doc, patchDoc))
+	if err != nil {
+		return nil, err
+	}
+	event := &models.Event{}
+	if err := json.Unmarshal(merged, event); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors1.As(err, &typeErr) {
+			return nil, &errors.ValidationError{
+				Field:   typeErr.Field,
+				Message: "has the wrong type",
+			}
+		}
+		return nil, &errors.ValidationError{
+			Field:   "request_body",
+			Message: "invalid JSON format",
+		}
+	}
+	event.EventID = eventID
+	event.Version = version
+	if err := s.UpdateEvent(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// mergePatch merges patch into target as described in RFC 7396.
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
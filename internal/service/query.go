@@ -0,0 +1,204 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 500
+	maxCalendarIDs    = 50
+)
+
+// QueryEvents searches events of the given calendars within an inclusive date
+// range and returns one page of results.
+func (s *CalendarService) QueryEvents(params *models.EventQueryParams) (*models.EventPage, error) {
+	query, err := parseEventQuery(params)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.repo.QueryEvents(query)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	page := &models.EventPage{Events: []*models.Event{}}
+	if len(events) > query.Limit-1 {
+		events = events[:query.Limit-1]
+		last := events[len(events)-1]
+		page.NextCursor = encodeCursor(&models.EventCursor{Date: last.Date, StartTime: last.StartTime, EventID: last.EventID})
+	}
+	for _, event := range events {
+		if view := event.ViewFor(query.ViewerID); view != nil {
+			page.Events = append(page.Events, view)
+		}
+	}
+	return page, nil
+}
+
+// listEvents reads every event of the user's calendar between from and to,
+// page by page, in ascending order.
+func (s *CalendarService) listEvents(userID string, from, to time.Time, tags []string) ([]*models.Event, error) {
+	var events []*models.Event
+	query := &models.EventQuery{
+		ViewerID:    userID,
+		CalendarIDs: []string{userID},
+		Tags:        tags,
+		From:        from,
+		To:          to,
+		Limit:       maxQueryLimit,
+	}
+	for {
+		page, err := s.repo.QueryEvents(query)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, page...)
+		if len(page) < query.Limit {
+			return events, nil
+		}
+		last := page[len(page)-1]
+		query.After = &models.EventCursor{Date: last.Date, StartTime: last.StartTime, EventID: last.EventID}
+	}
+}
+
+func parseEventQuery(params *models.EventQueryParams) (*models.EventQuery, error) {
+	query := &models.EventQuery{ViewerID: params.ViewerID}
+	calendarIDs, err := parseCalendarIDs(params.CalendarIDs)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := parseDateRange(params.From, params.To)
+	if err != nil {
+		return nil, err
+	}
+	query.CalendarIDs = calendarIDs
+	query.From = from
+	query.To = to
+	query.Text = params.Text
+	query.Tags, err = normalizeTags(params.Tags)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range params.Statuses {
+		switch status {
+		case models.StatusConfirmed, models.StatusTentative, models.StatusCancelled:
+			query.Statuses = append(query.Statuses, status)
+		default:
+			return nil, &errors.ValidationError{
+				Field:   "status",
+				Message: "must be one of confirmed, tentative, cancelled",
+			}
+		}
+	}
+	switch params.Sort {
+	case "", models.SortAsc:
+	case models.SortDesc:
+		query.Desc = true
+	default:
+		return nil, &errors.ValidationError{
+			Field:   "sort",
+			Message: "must be date or -date",
+		}
+	}
+	if params.Cursor != "" {
+		cursor, err := decodeCursor(params.Cursor)
+		if err != nil {
+			return nil, &errors.ValidationError{
+				Field:   "cursor",
+				Message: "invalid cursor",
+			}
+		}
+		query.After = cursor
+	}
+	limit := defaultQueryLimit
+	if params.Limit != "" {
+		limit, err = strconv.Atoi(params.Limit)
+		if err != nil || limit <= 0 || limit > maxQueryLimit {
+			return nil, &errors.ValidationError{
+				Field:   "limit",
+				Message: "must be a number between 1 and 500",
+			}
+		}
+	}
+	// One extra row tells whether there is a next page.
+	query.Limit = limit + 1
+	return query, nil
+}
+
+func parseCalendarIDs(ids []string) ([]string, error) {
+	var calendarIDs []string
+	for _, id := range ids {
+		if id != "" {
+			calendarIDs = append(calendarIDs, id)
+		}
+	}
+	if len(calendarIDs) == 0 {
+		return nil, &errors.ValidationError{
+			Field:   "calendar_ids",
+			Message: "can't be empty",
+		}
+	}
+	if len(calendarIDs) > maxCalendarIDs {
+		return nil, &errors.ValidationError{
+			Field:   "calendar_ids",
+			Message: "can't contain more than 50 calendars",
+		}
+	}
+	return calendarIDs, nil
+}
+
+func parseDateRange(fromStr, toStr string) (time.Time, time.Time, error) {
+	from, err := time.Parse(time.DateOnly, fromStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, &errors.ValidationError{
+			Field:   "from",
+			Message: "format must be YYYY-MM-DD",
+		}
+	}
+	to, err := time.Parse(time.DateOnly, toStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, &errors.ValidationError{
+			Field:   "to",
+			Message: "format must be YYYY-MM-DD",
+		}
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, &errors.ValidationError{
+			Field:   "to",
+			Message: "can't be before from",
+		}
+	}
+	return from, to, nil
+}
+
+func encodeCursor(cursor *models.EventCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*models.EventCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var cursor models.EventCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if _, err := time.Parse(time.DateOnly, cursor.Date); err != nil {
+		return nil, err
+	}
+	if cursor.StartTime != "" {
+		if _, err := time.Parse("15:04", cursor.StartTime); err != nil {
+			return nil, err
+		}
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"slices"
+)
+
+// QuotaConfig limits what a single user can store. MaxEvents caps the events
+// of a calendar, not counting the trash; MaxCalendars caps the calendars a
+// user has created events in, their own included. Zero turns a limit off.
+// Restoring an event from the trash counts against MaxEvents again.
+type QuotaConfig struct {
+	MaxEvents    int `yaml:"max_events" env:"MAX_EVENTS" env-default:"10000"`
+	MaxCalendars int `yaml:"max_calendars" env:"MAX_CALENDARS" env-default:"20"`
+}
+
+// checkCreateQuota checks that the acting user may create added events in
+// the calendar of userID. The repository checks the events quota again within
+// the write, where concurrent creates can't race past it.
+func (s *CalendarService) checkCreateQuota(userID string, added int) error {
+	if err := s.checkEventQuota(userID, added); err != nil {
+		return err
+	}
+	if s.quota.MaxCalendars <= 0 {
+		return nil
+	}
+	creator := s.actor
+	if creator == "" {
+		creator = userID
+	}
+	calendarIDs, err := s.repo.GetCalendarsCreatedBy(creator)
+	if err != nil {
+		return &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	if !slices.Contains(calendarIDs, userID) && len(calendarIDs) >= s.quota.MaxCalendars {
+		return &errors.QuotaExceededError{Resource: "calendars", Limit: s.quota.MaxCalendars}
+	}
+	return nil
+}
+
+// checkEventQuota checks that the calendar of userID has room for added
+// events.
+func (s *CalendarService) checkEventQuota(userID string, added int) error {
+	if s.quota.MaxEvents <= 0 {
+		return nil
+	}
+	count, err := s.repo.CountEvents(userID)
+	if err != nil {
+		return &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	if count+added > s.quota.MaxEvents {
+		return &errors.QuotaExceededError{Resource: "events", Limit: s.quota.MaxEvents}
+	}
+	return nil
+}
+
+// checkMoveQuota checks that the calendar event is moved to, if it is moved,
+// has room for it.
+func (s *CalendarService) checkMoveQuota(event *models.Event) error {
+	if s.quota.MaxEvents <= 0 {
+		return nil
+	}
+	current, err := s.repo.GetEvent(event.EventID)
+	if err != nil || current.UserID == event.UserID {
+		// A missing event is reported by the update itself.
+		return nil
+	}
+	return s.checkEventQuota(event.UserID, 1)
+}
+
+// checkOperationQuota checks a prepared batch operation. added counts the
+// events the batch creates per calendar up to and including op.
+func (s *CalendarService) checkOperationQuota(op *models.BatchOperation, added map[string]int) error {
+	switch op.Op {
+	case models.BatchCreate:
+		added[op.Event.UserID]++
+		return s.checkCreateQuota(op.Event.UserID, added[op.Event.UserID])
+	case models.BatchUpdate:
+		return s.checkMoveQuota(op.Event)
+	}
+	return nil
+}
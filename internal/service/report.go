@@ -0,0 +1,87 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"math"
+	"time"
+)
+
+// maxReportDays limits a report to one year of events.
+const maxReportDays = 366
+
+// GetReport returns the hours spent in the events of the calendars between
+// from and to, grouped by calendar, tag or attendee and split into day, week
+// or month buckets. Weeks start on the viewer's week start day.
+func (s *CalendarService) GetReport(params *models.ReportParams) (*models.Report, error) {
+	calendarIDs, err := parseCalendarIDs(params.CalendarIDs)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := parseDateRange(params.From, params.To)
+	if err != nil {
+		return nil, err
+	}
+	if to.Sub(from) >= maxReportDays*24*time.Hour {
+		return nil, &errors.ValidationError{
+			Field:   "to",
+			Message: "range can't be longer than 366 days",
+		}
+	}
+	query := &models.ReportQuery{
+		ViewerID:    params.ViewerID,
+		CalendarIDs: calendarIDs,
+		From:        from,
+		To:          to,
+		Bucket:      params.Bucket,
+		GroupBy:     params.GroupBy,
+		WeekStart:   time.Monday,
+	}
+	switch query.Bucket {
+	case "":
+		query.Bucket = models.BucketWeek
+	case models.BucketDay, models.BucketWeek, models.BucketMonth:
+	default:
+		return nil, &errors.ValidationError{
+			Field:   "bucket",
+			Message: "must be one of day, week, month",
+		}
+	}
+	switch query.GroupBy {
+	case "":
+		query.GroupBy = models.GroupByCalendar
+	case models.GroupByCalendar, models.GroupByTag, models.GroupByAttendee:
+	default:
+		return nil, &errors.ValidationError{
+			Field:   "group_by",
+			Message: "must be one of calendar, tag, attendee",
+		}
+	}
+	if query.Bucket == models.BucketWeek && query.ViewerID != "" {
+		settings, err := s.repo.GetUserSettings(query.ViewerID)
+		if err != nil {
+			return nil, &errors.BusinessError{
+				Message: err.Error(),
+			}
+		}
+		query.WeekStart = weekStartDay(settings.WeekStart)
+	}
+	rows, err := s.repo.Report(query)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	report := &models.Report{
+		From:    from.Format(time.DateOnly),
+		To:      to.Format(time.DateOnly),
+		Bucket:  query.Bucket,
+		GroupBy: query.GroupBy,
+		Rows:    []*models.ReportRow{},
+	}
+	for _, row := range rows {
+		row.Hours = math.Round(row.Hours*100) / 100
+		report.Rows = append(report.Rows, row)
+	}
+	return report, nil
+}
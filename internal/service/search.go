@@ -0,0 +1,83 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchLength    = 200
+)
+
+// SearchEvents finds events by words in their text in the given calendars,
+// the caller's own by default. Events of other calendars are only found if
+// they are public, since the text of the others is hidden from the caller.
+// Without a language the query is stemmed both as Russian and as English.
+func (s *CalendarService) SearchEvents(userID string, calendarIDs []string, text string, language string, limitStr string) ([]*models.SearchResult, error) {
+	if userID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	if len(calendarIDs) == 0 {
+		calendarIDs = []string{userID}
+	}
+	calendarIDs, err := parseCalendarIDs(calendarIDs)
+	if err != nil {
+		return nil, err
+	}
+	if text == "" {
+		return nil, &errors.ValidationError{
+			Field:   "q",
+			Message: "can't be empty",
+		}
+	}
+	if utf8.RuneCountInString(text) > maxSearchLength {
+		return nil, &errors.ValidationError{
+			Field:   "q",
+			Message: "can't be longer than 200 characters",
+		}
+	}
+	var languages [2]string
+	switch language {
+	case "":
+		languages = [2]string{models.LanguageRussian, models.LanguageEnglish}
+	case "ru", models.LanguageRussian:
+		languages = [2]string{models.LanguageRussian, models.LanguageRussian}
+	case "en", models.LanguageEnglish:
+		languages = [2]string{models.LanguageEnglish, models.LanguageEnglish}
+	default:
+		return nil, &errors.ValidationError{
+			Field:   "lang",
+			Message: "must be ru or en",
+		}
+	}
+	limit := defaultSearchLimit
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > maxSearchLimit {
+			return nil, &errors.ValidationError{
+				Field:   "limit",
+				Message: "must be a number between 1 and 100",
+			}
+		}
+	}
+	results, err := s.repo.SearchEvents(userID, calendarIDs, text, languages, limit)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	visible := []*models.SearchResult{}
+	for _, result := range results {
+		if result.Event = result.Event.ViewFor(userID); result.Event != nil {
+			visible = append(visible, result)
+		}
+	}
+	return visible, nil
+}
@@ -5,63 +5,141 @@ import (
 	"Calendar/internal/models"
 	"Calendar/internal/repository"
 	"context"
+	errors1 "errors"
 	"github.com/google/uuid"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// maxReminderOffset limits reminders to four weeks before the event.
+const maxReminderOffset = 4 * 7 * 24 * 60
+
+// Length limits of the event text fields, in characters.
+const (
+	maxTitleLength       = 255
+	maxDescriptionLength = 10000
+	maxLocationLength    = 1024
+	maxURLLength         = 2048
+	maxTagLength         = 64
+	maxEventTags         = 20
+	maxAttendeeLength    = 255
+	maxEventAttendees    = 100
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
 type CalendarServiceInterface interface {
 	CreateEvent(event *models.Event) (string, error)
-	GetEventsForDay(userID string, dateStr string) ([]*models.Event, error)
-	GetEventsForWeek(userID string, dateStr string) ([]*models.Event, error)
-	GetEventsForMonth(userID string, dateStr string) ([]*models.Event, error)
-	DeleteEvent(eventID string) error
+	GetEvent(eventID string, viewerID string) (*models.Event, error)
+	GetEventsForDay(userID string, dateStr string, tags []string) ([]*models.Event, error)
+	GetEventsForWeek(userID string, dateStr string, window string, tags []string) ([]*models.Event, error)
+	GetEventsForMonth(userID string, dateStr string, window string, tags []string) ([]*models.Event, error)
+	DeleteEvent(eventID string, version int64) error
+	GetTrash(userID string, limitStr string) ([]*models.Event, error)
+	RestoreEvent(eventID string) (*models.Event, error)
 	UpdateEvent(event *models.Event) error
+	PatchEvent(eventID string, patch []byte, version int64) (*models.Event, error)
+	ApplyBatch(batch *models.Batch) ([]*models.BatchResult, error)
+	GetHistory(eventID string, viewerID string) ([]*models.HistoryEntry, error)
+	Audit(params *models.AuditParams) (*models.AuditPage, error)
+	RevertEvent(eventID string, userID string, revision int, version int64) (*models.Event, error)
+	Sync(userID string, token string) (*models.SyncResult, error)
+	QueryEvents(params *models.EventQueryParams) (*models.EventPage, error)
+	SearchEvents(userID string, calendarIDs []string, text string, language string, limitStr string) ([]*models.SearchResult, error)
+	GetUserSettings(userID string) (*models.UserSettings, error)
+	GetUsersSettings(userIDs []string) ([]*models.UserSettings, error)
+	UpdateUserSettings(settings *models.UserSettings) error
+	GetTags(userID string) ([]*models.Tag, error)
+	RenameTag(tag *models.Tag) error
+	MergeTags(merge *models.TagMerge) error
+	DeleteTag(userID string, tagID string) error
+	GetReport(params *models.ReportParams) (*models.Report, error)
 }
 
 type CalendarService struct {
-	repo repository.CalendarRepositoryInterface
-	ctx  context.Context
+	repo  repository.CalendarRepositoryInterface
+	ctx   context.Context
+	quota QuotaConfig
+	actor string
 }
 
-func NewCalendarService(ctx context.Context, repo repository.CalendarRepositoryInterface) *CalendarService {
+func NewCalendarService(ctx context.Context, quota QuotaConfig, repo repository.CalendarRepositoryInterface) *CalendarService {
 	return &CalendarService{
-		ctx:  ctx,
-		repo: repo,
+		ctx:   ctx,
+		repo:  repo,
+		quota: quota,
 	}
 }
 
 func (s *CalendarService) CreateEvent(event *models.Event) (string, error) {
+	if err := validateNewEvent(event); err != nil {
+		return "", err
+	}
+	if err := s.checkCreateQuota(event.UserID, 1); err != nil {
+		return "", err
+	}
+	id := uuid.New().String()
+	event.EventID = id
+	err := s.repo.CreateEvent(event)
+	if err != nil {
+		return "", repositoryError(err, id)
+	}
+	return id, nil
+}
+
+// validateNewEvent checks an event before it is created.
+func validateNewEvent(event *models.Event) error {
 	if event.UserID == "" {
-		return "", &errors.ValidationError{
+		return &errors.ValidationError{
 			Field:   "user_id",
 			Message: "can't be empty",
 		}
 	}
 	if event.Event == "" {
-		return "", &errors.ValidationError{
+		return &errors.ValidationError{
 			Field:   "event",
 			Message: "can't be empty",
 		}
 	}
 	_, err := time.Parse(time.DateOnly, event.Date)
 	if err != nil {
-		return "", &errors.ValidationError{
+		return &errors.ValidationError{
 			Field:   "date",
 			Message: "format must be YYYY-MM-DD",
 		}
 	}
-	id := uuid.New().String()
-	event.EventID = id
-	err = s.repo.CreateEvent(event)
-	if err != nil {
-		return "", &errors.BusinessError{
-			Message: err.Error(),
+	if err := validateDetails(event); err != nil {
+		return err
+	}
+	return validateSchedule(event)
+}
+
+// GetEvent returns the event as the viewer is allowed to see it. Private
+// events of other users are reported as missing, so their existence isn't
+// revealed.
+func (s *CalendarService) GetEvent(eventID string, viewerID string) (*models.Event, error) {
+	if eventID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "event_id",
+			Message: "event id can't be empty",
 		}
 	}
-	return id, nil
+	event, err := s.repo.GetEvent(eventID)
+	if err != nil {
+		return nil, repositoryError(err, eventID)
+	}
+	event = event.ViewFor(viewerID)
+	if event == nil {
+		return nil, &errors.NotFoundError{Resource: "event", ID: eventID}
+	}
+	return event, nil
 }
 
-func (s *CalendarService) GetEventsForDay(userID string, dateStr string) ([]*models.Event, error) {
+func (s *CalendarService) GetEventsForDay(userID string, dateStr string, tags []string) ([]*models.Event, error) {
 	if userID == "" {
 		return nil, &errors.ValidationError{
 			Field:   "user_id",
@@ -81,7 +159,11 @@ func (s *CalendarService) GetEventsForDay(userID string, dateStr string) ([]*mod
 			Message: "format must be YYYY-MM-DD",
 		}
 	}
-	events, err := s.repo.GetEventsForDay(userID, date)
+	tags, err = normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.listEvents(userID, date, date, tags)
 	if err != nil {
 		return nil, &errors.BusinessError{
 			Message: err.Error(),
@@ -95,7 +177,11 @@ func (s *CalendarService) GetEventsForDay(userID string, dateStr string) ([]*mod
 	return events, nil
 }
 
-func (s *CalendarService) GetEventsForWeek(userID string, dateStr string) ([]*models.Event, error) {
+// GetEventsForWeek returns the events of the calendar week containing the date.
+// The week starts on the user's configured week start day. With the rolling
+// window it covers the date and the six days after it instead. If tags are
+// given, only events with at least one of them are returned.
+func (s *CalendarService) GetEventsForWeek(userID string, dateStr string, window string, tags []string) ([]*models.Event, error) {
 	if userID == "" {
 		return nil, &errors.ValidationError{
 			Field:   "user_id",
@@ -115,7 +201,24 @@ func (s *CalendarService) GetEventsForWeek(userID string, dateStr string) ([]*mo
 			Message: "format must be YYYY-MM-DD",
 		}
 	}
-	events, err := s.repo.GetEventsForWeek(userID, date)
+	if err := validateWindow(window); err != nil {
+		return nil, err
+	}
+	tags, err = normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	from, to := date, date.AddDate(0, 0, 6)
+	if window != models.WindowRolling {
+		settings, err := s.repo.GetUserSettings(userID)
+		if err != nil {
+			return nil, &errors.BusinessError{
+				Message: err.Error(),
+			}
+		}
+		from, to = weekWindow(date, weekStartDay(settings.WeekStart))
+	}
+	events, err := s.listEvents(userID, from, to, tags)
 	if err != nil {
 		return nil, &errors.BusinessError{
 			Message: err.Error(),
@@ -129,7 +232,11 @@ func (s *CalendarService) GetEventsForWeek(userID string, dateStr string) ([]*mo
 	return events, nil
 }
 
-func (s *CalendarService) GetEventsForMonth(userID string, dateStr string) ([]*models.Event, error) {
+// GetEventsForMonth returns the events of the calendar month containing the
+// date. With the rolling window it covers one month starting at the date,
+// without the day one month later. Tags filter the events like in
+// GetEventsForWeek.
+func (s *CalendarService) GetEventsForMonth(userID string, dateStr string, window string, tags []string) ([]*models.Event, error) {
 	if userID == "" {
 		return nil, &errors.ValidationError{
 			Field:   "user_id",
@@ -149,7 +256,18 @@ func (s *CalendarService) GetEventsForMonth(userID string, dateStr string) ([]*m
 			Message: "format must be YYYY-MM-DD",
 		}
 	}
-	events, err := s.repo.GetEventsForMonth(userID, date)
+	if err := validateWindow(window); err != nil {
+		return nil, err
+	}
+	tags, err = normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	from, to := monthWindow(date)
+	if window == models.WindowRolling {
+		from, to = date, date.AddDate(0, 1, -1)
+	}
+	events, err := s.listEvents(userID, from, to, tags)
 	if err != nil {
 		return nil, &errors.BusinessError{
 			Message: err.Error(),
@@ -163,35 +281,71 @@ func (s *CalendarService) GetEventsForMonth(userID string, dateStr string) ([]*m
 	return events, nil
 }
 
-func (s *CalendarService) DeleteEvent(eventID string) error {
+// DeleteEvent moves the event to the trash if it is still at the given
+// version.
+func (s *CalendarService) DeleteEvent(eventID string, version int64) error {
+	if err := validateDeletion(eventID, version); err != nil {
+		return err
+	}
+	err := s.repo.DeleteEvent(eventID, version)
+	if err != nil {
+		return repositoryError(err, eventID)
+	}
+	return nil
+}
+
+// UpdateEvent replaces the event if it is still at event.Version. On success
+// event.Version holds the new version.
+func (s *CalendarService) UpdateEvent(event *models.Event) error {
+	if err := validateChangedEvent(event); err != nil {
+		return err
+	}
+	if err := s.checkMoveQuota(event); err != nil {
+		return err
+	}
+	err := s.repo.UpdateEvent(event)
+	if err != nil {
+		return repositoryError(err, event.EventID)
+	}
+	return nil
+}
+
+func validateDeletion(eventID string, version int64) error {
 	if eventID == "" {
 		return &errors.ValidationError{
 			Field:   "event_id",
 			Message: "event id can't be empty",
 		}
 	}
-	err := s.repo.DeleteEvent(eventID)
-	if err != nil {
-		return &errors.BusinessError{
-			Message: err.Error(),
-		}
+	if version <= 0 {
+		return &errors.PreconditionRequiredError{Resource: "event", ID: eventID}
 	}
 	return nil
 }
 
-func (s *CalendarService) UpdateEvent(event *models.Event) error {
+// validateChangedEvent checks the new state of an existing event.
+func validateChangedEvent(event *models.Event) error {
 	if event.EventID == "" {
 		return &errors.ValidationError{
 			Field:   "event_id",
 			Message: "event id can't be empty",
 		}
 	}
+	if event.Version <= 0 {
+		return &errors.PreconditionRequiredError{Resource: "event", ID: event.EventID}
+	}
 	if event.UserID == "" {
 		return &errors.ValidationError{
 			Field:   "user_id",
 			Message: "user id can't be empty",
 		}
 	}
+	if event.Event == "" {
+		return &errors.ValidationError{
+			Field:   "event",
+			Message: "can't be empty",
+		}
+	}
 	_, err := time.Parse(time.DateOnly, event.Date)
 	if err != nil {
 		return &errors.ValidationError{
@@ -199,11 +353,251 @@ func (s *CalendarService) UpdateEvent(event *models.Event) error {
 			Message: "format must be YYYY-MM-DD",
 		}
 	}
-	err = s.repo.UpdateEvent(event)
+	if err := validateDetails(event); err != nil {
+		return err
+	}
+	return validateSchedule(event)
+}
+
+// repositoryError converts a missing event into a not found error, a version
+// conflict into a failed precondition and any other repository error into a
+// business error.
+func repositoryError(err error, eventID string) error {
+	var conflict *repository.VersionConflictError
+	if errors1.As(err, &conflict) {
+		return &errors.PreconditionFailedError{Resource: "event", ID: conflict.EventID, Version: conflict.Version}
+	}
+	var quota *repository.QuotaExceededError
+	if errors1.As(err, &quota) {
+		return &errors.QuotaExceededError{Resource: quota.Resource, Limit: quota.Limit}
+	}
+	if errors1.Is(err, repository.ErrEventNotFound) {
+		return &errors.NotFoundError{Resource: "event", ID: eventID}
+	}
+	return &errors.BusinessError{
+		Message: err.Error(),
+	}
+}
+
+func validateSchedule(event *models.Event) error {
+	var start time.Time
+	if event.StartTime != "" {
+		var err error
+		if start, err = time.Parse("15:04", event.StartTime); err != nil {
+			return &errors.ValidationError{
+				Field:   "start_time",
+				Message: "format must be HH:MM",
+			}
+		}
+	}
+	if event.EndTime != "" {
+		end, err := time.Parse("15:04", event.EndTime)
+		if err != nil {
+			return &errors.ValidationError{
+				Field:   "end_time",
+				Message: "format must be HH:MM",
+			}
+		}
+		if event.StartTime == "" || !end.After(start) {
+			return &errors.ValidationError{
+				Field:   "end_time",
+				Message: "must be after start_time",
+			}
+		}
+	}
+	for _, reminder := range event.Reminders {
+		if reminder == nil {
+			return &errors.ValidationError{
+				Field:   "reminders",
+				Message: "can't contain null",
+			}
+		}
+		if reminder.OffsetMinutes < 0 || reminder.OffsetMinutes > maxReminderOffset {
+			return &errors.ValidationError{
+				Field:   "offset_minutes",
+				Message: "must be between 0 and 40320",
+			}
+		}
+		switch reminder.Channel {
+		case models.ChannelLog:
+		case models.ChannelWebhook:
+			u, err := url.Parse(reminder.Target)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return &errors.ValidationError{
+					Field:   "target",
+					Message: "must be an http(s) URL for webhook reminders",
+				}
+			}
+		case models.ChannelEmail:
+			addr, err := mail.ParseAddress(reminder.Target)
+			if err != nil {
+				return &errors.ValidationError{
+					Field:   "target",
+					Message: "must be an email address for email reminders",
+				}
+			}
+			reminder.Target = addr.Address
+		default:
+			return &errors.ValidationError{
+				Field:   "channel",
+				Message: "must be one of log, webhook, email",
+			}
+		}
+	}
+	return nil
+}
+
+// validateDetails checks the descriptive fields of the event and fills in the
+// default status, visibility and transparency.
+func validateDetails(event *models.Event) error {
+	tags, err := normalizeTags(event.Tags)
 	if err != nil {
-		return &errors.BusinessError{
-			Message: err.Error(),
+		return err
+	}
+	event.Tags = tags
+	attendees, err := normalizeAttendees(event.Attendees)
+	if err != nil {
+		return err
+	}
+	event.Attendees = attendees
+	if utf8.RuneCountInString(event.Event) > maxTitleLength {
+		return &errors.ValidationError{
+			Field:   "event",
+			Message: "can't be longer than 255 characters",
+		}
+	}
+	if utf8.RuneCountInString(event.Description) > maxDescriptionLength {
+		return &errors.ValidationError{
+			Field:   "description",
+			Message: "can't be longer than 10000 characters",
+		}
+	}
+	if utf8.RuneCountInString(event.Location) > maxLocationLength {
+		return &errors.ValidationError{
+			Field:   "location",
+			Message: "can't be longer than 1024 characters",
+		}
+	}
+	if event.URL != "" {
+		u, err := url.Parse(event.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(event.URL) > maxURLLength {
+			return &errors.ValidationError{
+				Field:   "url",
+				Message: "must be an http(s) URL of at most 2048 characters",
+			}
+		}
+	}
+	if event.Color != "" {
+		if !colorPattern.MatchString(event.Color) {
+			return &errors.ValidationError{
+				Field:   "color",
+				Message: "format must be #RRGGBB",
+			}
+		}
+		event.Color = strings.ToLower(event.Color)
+	}
+	switch event.Status {
+	case "":
+		event.Status = models.StatusConfirmed
+	case models.StatusConfirmed, models.StatusTentative, models.StatusCancelled:
+	default:
+		return &errors.ValidationError{
+			Field:   "status",
+			Message: "must be one of confirmed, tentative, cancelled",
+		}
+	}
+	switch event.Visibility {
+	case "":
+		event.Visibility = models.VisibilityPublic
+	case models.VisibilityPublic, models.VisibilityPrivate, models.VisibilityBusy:
+	default:
+		return &errors.ValidationError{
+			Field:   "visibility",
+			Message: "must be one of public, private, busy",
+		}
+	}
+	switch event.Transparency {
+	case "":
+		event.Transparency = models.TransparencyOpaque
+	case models.TransparencyOpaque, models.TransparencyTransparent:
+	default:
+		return &errors.ValidationError{
+			Field:   "transparency",
+			Message: "must be one of opaque, transparent",
 		}
 	}
 	return nil
 }
+
+// normalizeTags trims the tag names and drops duplicates, which are compared
+// regardless of case.
+func normalizeTags(tags []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil, &errors.ValidationError{
+				Field:   "tags",
+				Message: "can't contain empty tags",
+			}
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, &errors.ValidationError{
+				Field:   "tags",
+				Message: "tag can't be longer than 64 characters",
+			}
+		}
+		key := strings.ToLower(tag)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, tag)
+	}
+	if len(result) > maxEventTags {
+		return nil, &errors.ValidationError{
+			Field:   "tags",
+			Message: "can't contain more than 20 tags",
+		}
+	}
+	return result, nil
+}
+
+// normalizeAttendees trims the attendees, which are user IDs or email
+// addresses, and drops duplicates.
+func normalizeAttendees(attendees []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]struct{}, len(attendees))
+	for _, attendee := range attendees {
+		attendee = strings.TrimSpace(attendee)
+		if attendee == "" || len(attendee) > maxAttendeeLength {
+			return nil, &errors.ValidationError{
+				Field:   "attendees",
+				Message: "attendee must be between 1 and 255 characters",
+			}
+		}
+		if strings.Contains(attendee, "@") {
+			addr, err := mail.ParseAddress(attendee)
+			if err != nil {
+				return nil, &errors.ValidationError{
+					Field:   "attendees",
+					Message: "invalid email address " + attendee,
+				}
+			}
+			attendee = addr.Address
+		}
+		if _, ok := seen[attendee]; ok {
+			continue
+		}
+		seen[attendee] = struct{}{}
+		result = append(result, attendee)
+	}
+	if len(result) > maxEventAttendees {
+		return nil, &errors.ValidationError{
+			Field:   "attendees",
+			Message: "can't contain more than 100 attendees",
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"time"
+)
+
+func (s *CalendarService) GetUserSettings(userID string) (*models.UserSettings, error) {
+	if userID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	settings, err := s.repo.GetUserSettings(userID)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	return settings, nil
+}
+
+// GetUsersSettings returns the settings of several users with one repository
+// call, in the order of userIDs.
+func (s *CalendarService) GetUsersSettings(userIDs []string) ([]*models.UserSettings, error) {
+	for _, userID := range userIDs {
+		if userID == "" {
+			return nil, &errors.ValidationError{
+				Field:   "user_id",
+				Message: "can't be empty",
+			}
+		}
+	}
+	settings, err := s.repo.GetUsersSettings(userIDs)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	return settings, nil
+}
+
+func (s *CalendarService) UpdateUserSettings(settings *models.UserSettings) error {
+	if settings.UserID == "" {
+		return &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	switch settings.WeekStart {
+	case "":
+		settings.WeekStart = models.WeekStartMonday
+	case models.WeekStartMonday, models.WeekStartSunday:
+	default:
+		return &errors.ValidationError{
+			Field:   "week_start",
+			Message: "must be monday or sunday",
+		}
+	}
+	err := s.repo.SaveUserSettings(settings)
+	if err != nil {
+		return &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+func validateWindow(window string) error {
+	switch window {
+	case "", models.WindowCalendar, models.WindowRolling:
+		return nil
+	default:
+		return &errors.ValidationError{
+			Field:   "window",
+			Message: "must be calendar or rolling",
+		}
+	}
+}
+
+func weekStartDay(weekStart string) time.Weekday {
+	if weekStart == models.WeekStartSunday {
+		return time.Sunday
+	}
+	return time.Monday
+}
+
+// weekWindow returns the first and the last day of the week containing date.
+func weekWindow(date time.Time, weekStart time.Weekday) (time.Time, time.Time) {
+	offset := (int(date.Weekday()) - int(weekStart) + 7) % 7
+	from := date.AddDate(0, 0, -offset)
+	return from, from.AddDate(0, 0, 6)
+}
+
+// monthWindow returns the first and the last day of the month containing date.
+func monthWindow(date time.Time) (time.Time, time.Time) {
+	from := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	return from, from.AddDate(0, 1, -1)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"encoding/base64"
+	"encoding/json"
+)
+
+const syncPageSize = 500
+
+type syncToken struct {
+	UserID string `json:"u"`
+	Seq    int64  `json:"s"`
+}
+
+// Sync returns the changes of the user's calendar since the state described by
+// token. An empty token starts a full sync. When HasMore is set the client
+// should call Sync again with the returned token straight away.
+func (s *CalendarService) Sync(userID string, token string) (*models.SyncResult, error) {
+	if userID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	afterSeq := int64(-1)
+	if token != "" {
+		decoded, err := decodeSyncToken(token)
+		if err != nil || decoded.UserID != userID {
+			return nil, &errors.ValidationError{
+				Field:   "sync_token",
+				Message: "invalid sync token, start a full sync",
+			}
+		}
+		afterSeq = decoded.Seq
+	}
+	changes, err := s.repo.SyncEvents(userID, afterSeq, syncPageSize+1)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	result := &models.SyncResult{
+		Created: []*models.Event{},
+		Updated: []*models.Event{},
+		Deleted: []*models.Tombstone{},
+	}
+	if len(changes) > syncPageSize {
+		changes = changes[:syncPageSize]
+		result.HasMore = true
+	}
+	lastSeq := afterSeq
+	for _, change := range changes {
+		switch {
+		case change.Tombstone != nil:
+			result.Deleted = append(result.Deleted, change.Tombstone)
+		case change.CreatedSeq > afterSeq:
+			result.Created = append(result.Created, change.Event)
+		default:
+			result.Updated = append(result.Updated, change.Event)
+		}
+		lastSeq = change.Seq
+	}
+	result.SyncToken = encodeSyncToken(syncToken{UserID: userID, Seq: lastSeq})
+	return result, nil
+}
+
+func encodeSyncToken(token syncToken) string {
+	data, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSyncToken(token string) (syncToken, error) {
+	var decoded syncToken
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return decoded, err
+	}
+	err = json.Unmarshal(data, &decoded)
+	return decoded, err
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	errors1 "errors"
+	"strings"
+	"unicode/utf8"
+)
+
+func (s *CalendarService) GetTags(userID string) ([]*models.Tag, error) {
+	if userID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	tags, err := s.repo.GetTags(userID)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	if tags == nil {
+		return []*models.Tag{}, nil
+	}
+	return tags, nil
+}
+
+// RenameTag renames the tag on every event carrying it. Renaming a tag to the
+// name of another tag fails; merge the tags instead.
+func (s *CalendarService) RenameTag(tag *models.Tag) error {
+	if tag.UserID == "" {
+		return &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	if tag.TagID == "" {
+		return &errors.ValidationError{
+			Field:   "tag_id",
+			Message: "can't be empty",
+		}
+	}
+	tag.Name = strings.TrimSpace(tag.Name)
+	if tag.Name == "" {
+		return &errors.ValidationError{
+			Field:   "name",
+			Message: "can't be empty",
+		}
+	}
+	if utf8.RuneCountInString(tag.Name) > maxTagLength {
+		return &errors.ValidationError{
+			Field:   "name",
+			Message: "can't be longer than 64 characters",
+		}
+	}
+	err := s.repo.RenameTag(tag)
+	if errors1.Is(err, repository.ErrTagExists) {
+		return &errors.ValidationError{
+			Field:   "name",
+			Message: "tag already exists, merge the tags instead",
+		}
+	}
+	if err != nil {
+		return &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+func (s *CalendarService) MergeTags(merge *models.TagMerge) error {
+	if merge.UserID == "" {
+		return &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	if merge.SourceTagID == "" {
+		return &errors.ValidationError{
+			Field:   "source_tag_id",
+			Message: "can't be empty",
+		}
+	}
+	if merge.TargetTagID == "" {
+		return &errors.ValidationError{
+			Field:   "target_tag_id",
+			Message: "can't be empty",
+		}
+	}
+	if merge.SourceTagID == merge.TargetTagID {
+		return &errors.ValidationError{
+			Field:   "target_tag_id",
+			Message: "must differ from source_tag_id",
+		}
+	}
+	err := s.repo.MergeTags(merge)
+	if err != nil {
+		return &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+// DeleteTag removes the tag from the catalogue and from every event.
+func (s *CalendarService) DeleteTag(userID string, tagID string) error {
+	if userID == "" {
+		return &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	if tagID == "" {
+		return &errors.ValidationError{
+			Field:   "tag_id",
+			Message: "can't be empty",
+		}
+	}
+	err := s.repo.DeleteTag(userID, tagID)
+	if err != nil {
+		return &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"strconv"
+)
+
+const (
+	defaultTrashLimit = 50
+	maxTrashLimit     = 500
+)
+
+// GetTrash returns the user's deleted events that haven't been purged yet,
+// the most recently deleted first.
+func (s *CalendarService) GetTrash(userID string, limitStr string) ([]*models.Event, error) {
+	if userID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	limit := defaultTrashLimit
+	if limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > maxTrashLimit {
+			return nil, &errors.ValidationError{
+				Field:   "limit",
+				Message: "must be a number between 1 and 500",
+			}
+		}
+	}
+	events, err := s.repo.GetTrash(userID, limit)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	if events == nil {
+		return []*models.Event{}, nil
+	}
+	return events, nil
+}
+
+// RestoreEvent moves a deleted event back to its calendar and returns it with
+// its new version.
+func (s *CalendarService) RestoreEvent(eventID string) (*models.Event, error) {
+	if eventID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "event_id",
+			Message: "event id can't be empty",
+		}
+	}
+	event, err := s.repo.RestoreEvent(eventID)
+	if err != nil {
+		return nil, repositoryError(err, eventID)
+	}
+	return event, nil
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	errors1 "errors"
+	"github.com/google/uuid"
+	"net/url"
+	"strconv"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+type WebhookServiceInterface interface {
+	CreateSubscription(sub *models.WebhookSubscription) (*models.WebhookSubscription, error)
+	GetSubscriptions(userID string) ([]*models.WebhookSubscription, error)
+	DeleteSubscription(userID string, subscriptionID string) error
+	GetDeliveries(userID string, subscriptionID string, status string, limitStr string) ([]*models.WebhookDelivery, error)
+	GetDeadLetters(userID string, limitStr string) ([]*models.WebhookDelivery, error)
+	Redeliver(userID string, deliveryID string) error
+}
+
+type WebhookService struct {
+	repo repository.WebhookRepositoryInterface
+	ctx  context.Context
+}
+
+func NewWebhookService(ctx context.Context, repo repository.WebhookRepositoryInterface) *WebhookService {
+	return &WebhookService{
+		ctx:  ctx,
+		repo: repo,
+	}
+}
+
+func (s *WebhookService) CreateSubscription(sub *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	if sub.UserID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, &errors.ValidationError{
+			Field:   "url",
+			Message: "must be an http(s) URL",
+		}
+	}
+	if len(sub.EventTypes) == 0 {
+		return nil, &errors.ValidationError{
+			Field:   "event_types",
+			Message: "can't be empty",
+		}
+	}
+	for _, eventType := range sub.EventTypes {
+		switch eventType {
+		case models.EventCreated, models.EventUpdated, models.EventDeleted:
+		default:
+			return nil, &errors.ValidationError{
+				Field:   "event_types",
+				Message: "must contain only event.created, event.updated, event.deleted",
+			}
+		}
+	}
+	if sub.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return nil, &errors.BusinessError{
+				Message: err.Error(),
+			}
+		}
+		sub.Secret = secret
+	}
+	sub.SubscriptionID = uuid.New().String()
+	err = s.repo.CreateSubscription(sub)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	return sub, nil
+}
+
+func (s *WebhookService) GetSubscriptions(userID string) ([]*models.WebhookSubscription, error) {
+	if userID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	subs, err := s.repo.GetSubscriptions(userID)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	if subs == nil {
+		return []*models.WebhookSubscription{}, nil
+	}
+	return subs, nil
+}
+
+// DeleteSubscription deletes the user's webhook. Webhooks of other users
+// aren't found.
+func (s *WebhookService) DeleteSubscription(userID string, subscriptionID string) error {
+	if userID == "" {
+		return &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	if subscriptionID == "" {
+		return &errors.ValidationError{
+			Field:   "id",
+			Message: "webhook id can't be empty",
+		}
+	}
+	err := s.repo.DeleteSubscription(userID, subscriptionID)
+	if err != nil {
+		return webhookError(err, subscriptionID)
+	}
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of the user's webhook.
+func (s *WebhookService) GetDeliveries(userID string, subscriptionID string, status string, limitStr string) ([]*models.WebhookDelivery, error) {
+	if userID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	if subscriptionID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "subscription_id",
+			Message: "can't be empty",
+		}
+	}
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryProcessing, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		return nil, &errors.ValidationError{
+			Field:   "status",
+			Message: "must be one of pending, processing, delivered, dead",
+		}
+	}
+	limit, err := parseLimit(limitStr)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := s.repo.GetDeliveries(userID, subscriptionID, status, limit)
+	if err != nil {
+		return nil, webhookError(err, subscriptionID)
+	}
+	if deliveries == nil {
+		return []*models.WebhookDelivery{}, nil
+	}
+	return deliveries, nil
+}
+
+func (s *WebhookService) GetDeadLetters(userID string, limitStr string) ([]*models.WebhookDelivery, error) {
+	if userID == "" {
+		return nil, &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	limit, err := parseLimit(limitStr)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := s.repo.GetDeadLetters(userID, limit)
+	if err != nil {
+		return nil, &errors.BusinessError{
+			Message: err.Error(),
+		}
+	}
+	if deliveries == nil {
+		return []*models.WebhookDelivery{}, nil
+	}
+	return deliveries, nil
+}
+
+// Redeliver schedules a dead delivery of the user's webhooks again.
+func (s *WebhookService) Redeliver(userID string, deliveryID string) error {
+	if userID == "" {
+		return &errors.ValidationError{
+			Field:   "user_id",
+			Message: "can't be empty",
+		}
+	}
+	if deliveryID == "" {
+		return &errors.ValidationError{
+			Field:   "id",
+			Message: "delivery id can't be empty",
+		}
+	}
+	err := s.repo.Redeliver(userID, deliveryID)
+	if err != nil {
+		return webhookError(err, deliveryID)
+	}
+	return nil
+}
+
+// webhookError maps a webhook repository error to the error reported for it.
+func webhookError(err error, id string) error {
+	if errors1.Is(err, repository.ErrWebhookNotFound) {
+		return &errors.NotFoundError{Resource: "webhook", ID: id}
+	}
+	if errors1.Is(err, repository.ErrDeliveryNotFound) {
+		return &errors.NotFoundError{Resource: "delivery", ID: id}
+	}
+	return &errors.BusinessError{
+		Message: err.Error(),
+	}
+}
+
+func parseLimit(limitStr string) (int, error) {
+	if limitStr == "" {
+		return defaultDeliveriesLimit, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 || limit > maxDeliveriesLimit {
+		return 0, &errors.ValidationError{
+			Field:   "limit",
+			Message: "must be a number between 1 and 500",
+		}
+	}
+	return limit, nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
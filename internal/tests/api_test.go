@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"Calendar/internal/config"
+	"Calendar/internal/models"
+	"Calendar/internal/service"
+	"Calendar/internal/transport"
+	"Calendar/pkg/logger"
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// FakeCalendarService records the calls the HTTP layer makes.
+type FakeCalendarService struct {
+	service.CalendarServiceInterface
+	query   *models.EventQueryParams
+	deleted string
+}
+
+func (f *FakeCalendarService) CreateEvent(event *models.Event) (string, error) {
+	event.EventID = "e1"
+	event.Version = 1
+	return event.EventID, nil
+}
+
+func (f *FakeCalendarService) DeleteEvent(eventID string, version int64) error {
+	f.deleted = eventID
+	return service.NewCalendarService(context.Background(), service.QuotaConfig{}, &MockRepository{}).DeleteEvent(eventID, version)
+}
+
+func (f *FakeCalendarService) QueryEvents(params *models.EventQueryParams) (*models.EventPage, error) {
+	f.query = params
+	return &models.EventPage{Events: []*models.Event{}}, nil
+}
+
+// testAdminKey is the admin key of routers made by newTestRouter.
+const testAdminKey = "admin"
+
+func newTestRouter(t *testing.T, srv service.CalendarServiceInterface) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ctx, err := logger.New(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transport.NewCalendarServer(ctx, &config.Config{AdminKeys: []string{testAdminKey}}, srv, nil, nil, nil).Router()
+}
+
+func serve(router http.Handler, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIV2(t *testing.T) {
+	fake := &FakeCalendarService{}
+	router := newTestRouter(t, fake)
+
+	rec := serve(router, http.MethodPost, "/api/v2/events", `{"user_id": "1", "event": "standup", "date": "2025-09-29"}`, nil)
+	if rec.Code != http.StatusCreated || rec.Header().Get("Location") != "/api/v2/events/e1" || rec.Header().Get("ETag") != `"1"` {
+		t.Errorf("create = %d %v, want 201 with Location and ETag", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("Deprecation") != "" {
+		t.Errorf("v2 response is marked deprecated")
+	}
+
+	rec = serve(router, http.MethodDelete, "/api/v2/events/e1", "", nil)
+	if rec.Code != http.StatusPreconditionRequired {
+		t.Errorf("delete without version = %d, want 428", rec.Code)
+	}
+	rec = serve(router, http.MethodDelete, "/api/v2/events/e1", "", map[string]string{"If-Match": `"2"`})
+	if rec.Code != http.StatusNoContent || fake.deleted != "e1" {
+		t.Errorf("delete = %d, want 204", rec.Code)
+	}
+	rec = serve(router, http.MethodDelete, "/api/v2/events/e1", "", map[string]string{"If-Match": "*"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("delete with If-Match * = %d, want 400", rec.Code)
+	}
+
+	rec = serve(router, http.MethodGet, "/api/v2/users/7/events?from=2025-09-01&to=2025-09-30&tags=oncall", "", nil)
+	if rec.Code != http.StatusOK || fake.query.ViewerID != "7" || len(fake.query.CalendarIDs) != 1 || fake.query.CalendarIDs[0] != "7" {
+		t.Errorf("user events = %d %+v", rec.Code, fake.query)
+	}
+
+	rec = serve(router, http.MethodGet, "/api/v1/events?calendar_ids=7&from=2025-09-01&to=2025-09-30", "", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Deprecation") == "" || !strings.Contains(rec.Header().Get("Link"), "/api/v2") {
+		t.Errorf("v1 = %d %v, want deprecation headers", rec.Code, rec.Header())
+	}
+}
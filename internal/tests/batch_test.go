@@ -0,0 +1,246 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/service"
+	"context"
+	"encoding/json"
+	errors1 "errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"testing"
+)
+
+// MemoryRepository keeps events in a map and deleted ones in the trash.
+// ApplyBatch restores the events when an operation fails, like the rolled
+// back transaction.
+type MemoryRepository struct {
+	repository.CalendarRepositoryInterface
+	events map[string]models.Event
+	trash  map[string]models.Event
+}
+
+func newMemoryRepository(events ...models.Event) *MemoryRepository {
+	repo := &MemoryRepository{events: make(map[string]models.Event), trash: make(map[string]models.Event)}
+	for _, event := range events {
+		repo.events[event.EventID] = event
+	}
+	return repo
+}
+
+func (m *MemoryRepository) CreateEvent(event *models.Event) error {
+	event.Version = 1
+	m.events[event.EventID] = *event
+	return nil
+}
+
+func (m *MemoryRepository) UpdateEvent(event *models.Event) error {
+	current, ok := m.events[event.EventID]
+	if !ok {
+		return fmt.Errorf("%w with id: %s", repository.ErrEventNotFound, event.EventID)
+	}
+	if current.Version != event.Version {
+		return &repository.VersionConflictError{EventID: event.EventID, Version: current.Version}
+	}
+	event.Version++
+	m.events[event.EventID] = *event
+	return nil
+}
+
+func (m *MemoryRepository) DeleteEvent(eventID string, version int64) error {
+	current, ok := m.events[eventID]
+	if !ok {
+		return fmt.Errorf("%w with id: %s", repository.ErrEventNotFound, eventID)
+	}
+	if current.Version != version {
+		return &repository.VersionConflictError{EventID: eventID, Version: current.Version}
+	}
+	delete(m.events, eventID)
+	m.trash[eventID] = current
+	return nil
+}
+
+func (m *MemoryRepository) ApplyBatch(operations []*models.BatchOperation) error {
+	events, trash := maps.Clone(m.events), maps.Clone(m.trash)
+	for i, op := range operations {
+		var err error
+		switch op.Op {
+		case models.BatchCreate:
+			err = m.CreateEvent(op.Event)
+		case models.BatchUpdate:
+			err = m.UpdateEvent(op.Event)
+		case models.BatchDelete:
+			err = m.DeleteEvent(op.EventID, op.Version)
+		}
+		if err != nil {
+			m.events, m.trash = events, trash
+			return &repository.BatchError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+func batchOperations() []*models.BatchOperation {
+	return []*models.BatchOperation{
+		{Op: models.BatchCreate, Event: &models.Event{UserID: "1", Date: "2025-09-29", Event: "retro"}},
+		{Op: models.BatchUpdate, Event: &models.Event{UserID: "1", EventID: "e1", Date: "2025-09-30", Event: "standup", Version: 1}},
+		{Op: models.BatchDelete, EventID: "e2", Version: 1},
+	}
+}
+
+func storedEvents() []models.Event {
+	return []models.Event{
+		{UserID: "1", EventID: "e1", Date: "2025-09-29", Event: "standup", Version: 1},
+		{UserID: "1", EventID: "e2", Date: "2025-09-29", Event: "lunch", Version: 1},
+	}
+}
+
+func TestCalendarService_ApplyBatch(t *testing.T) {
+	isAborted := func(index int) func(err error) bool {
+		return func(err error) bool {
+			var target *errors.AbortedError
+			return errors1.As(err, &target) && target.Index == index
+		}
+	}
+	isStale := func(err error) bool {
+		var target *errors.PreconditionFailedError
+		return errors1.As(err, &target)
+	}
+	isNotFound := func(err error) bool {
+		var target *errors.NotFoundError
+		return errors1.As(err, &target)
+	}
+
+	tests := []struct {
+		name   string
+		mode   string
+		modify func(ops []*models.BatchOperation)
+		checks []func(err error) bool
+		stored int
+	}{
+		{
+			name:   "atomic",
+			checks: []func(err error) bool{nil, nil, nil},
+			stored: 2,
+		},
+		{
+			name:   "atomic stale update",
+			modify: func(ops []*models.BatchOperation) { ops[1].Event.Version = 5 },
+			checks: []func(err error) bool{isAborted(1), isStale, isAborted(1)},
+			stored: 2,
+		},
+		{
+			name:   "atomic invalid create",
+			modify: func(ops []*models.BatchOperation) { ops[0].Event.Date = "29.09.2025" },
+			checks: []func(err error) bool{isValidationError("date"), isAborted(0), isAborted(0)},
+			stored: 2,
+		},
+		{
+			name:   "best effort",
+			mode:   models.BatchBestEffort,
+			modify: func(ops []*models.BatchOperation) { ops[2].EventID = "e3" },
+			checks: []func(err error) bool{nil, nil, isNotFound},
+			stored: 3,
+		},
+		{
+			name:   "best effort unknown op",
+			mode:   models.BatchBestEffort,
+			modify: func(ops []*models.BatchOperation) { ops[1].Op = "move" },
+			checks: []func(err error) bool{nil, isValidationError("op"), nil},
+			stored: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryRepository(storedEvents()...)
+			srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+			ops := batchOperations()
+			if tt.modify != nil {
+				tt.modify(ops)
+			}
+			results, err := srv.ApplyBatch(&models.Batch{Mode: tt.mode, Operations: ops})
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			for i, check := range tt.checks {
+				if check == nil && results[i].Err != nil || check != nil && !check(results[i].Err) {
+					t.Errorf("result %d = %v", i, results[i].Err)
+				}
+			}
+			if len(repo.events) != tt.stored {
+				t.Errorf("stored %d events, want %d", len(repo.events), tt.stored)
+			}
+		})
+	}
+
+	repo := newMemoryRepository(storedEvents()...)
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+	results, _ := srv.ApplyBatch(&models.Batch{Operations: batchOperations()})
+	if results[0].Event == nil || results[0].Event.EventID == "" || results[0].Event.Version != 1 {
+		t.Errorf("created = %+v, want the stored event", results[0].Event)
+	}
+	if results[1].Event == nil || results[1].Event.Version != 2 || repo.events["e1"].Date != "2025-09-30" {
+		t.Errorf("updated = %+v, want version 2", results[1].Event)
+	}
+	if results[2].Event != nil {
+		t.Errorf("deleted = %+v, want no event", results[2].Event)
+	}
+
+	invalid := []struct {
+		name  string
+		batch *models.Batch
+		field string
+	}{
+		{name: "unknown mode", batch: &models.Batch{Mode: "some", Operations: batchOperations()}, field: "mode"},
+		{name: "no operations", batch: &models.Batch{}, field: "operations"},
+		{name: "null operation", batch: &models.Batch{Operations: []*models.BatchOperation{nil}}, field: "operations"},
+		{name: "too many operations", batch: &models.Batch{Operations: make([]*models.BatchOperation, 101)}, field: "operations"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := srv.ApplyBatch(tt.batch); !isValidationError(tt.field)(err) {
+				t.Errorf("error = %v, want validation error on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestAPI_Batch(t *testing.T) {
+	router := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, newMemoryRepository(storedEvents()...)))
+
+	rec := serve(router, http.MethodPost, "/api/v2/events/batch", `{"operations": [
+		{"op": "create", "event": {"user_id": "1", "date": "2025-09-29", "event": "retro"}},
+		{"op": "delete", "event_id": "e2", "version": 3}
+	]}`, nil)
+	var resp struct {
+		Mode    string
+		Results []struct {
+			Status int
+			Event  *models.Event
+			Error  *struct {
+				Error   string
+				Details map[string]string
+			}
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("batch = %d %s", rec.Code, rec.Body)
+	}
+	if resp.Mode != models.BatchAtomic || len(resp.Results) != 2 {
+		t.Fatalf("response = %s", rec.Body)
+	}
+	if r := resp.Results[0]; r.Status != http.StatusFailedDependency || r.Error == nil || r.Error.Error != "aborted" || r.Event != nil {
+		t.Errorf("create = %+v, want aborted", r)
+	}
+	if r := resp.Results[1]; r.Status != http.StatusPreconditionFailed || r.Error == nil || r.Error.Details["version"] != "1" {
+		t.Errorf("delete = %+v, want precondition_failed with version 1", r)
+	}
+
+	rec = serve(router, http.MethodPost, "/api/v2/events/batch", `[]`, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("array body = %d, want 400", rec.Code)
+	}
+}
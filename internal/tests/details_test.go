@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/service"
+	"context"
+	errors1 "errors"
+	"strings"
+	"testing"
+)
+
+func TestCalendarService_EventDetails(t *testing.T) {
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, &MockRepository{})
+
+	event := &models.Event{
+		UserID:      "1",
+		Event:       "event",
+		Date:        "2025-09-29",
+		Description: strings.Repeat("д", 10000),
+		URL:         "https://example.com/meeting",
+		Color:       "#A1B2C3",
+		StartTime:   "10:00",
+		EndTime:     "11:30",
+		Attendees:   []string{"2", "Bob <bob@example.com>", "bob@example.com"},
+	}
+	if _, err := srv.CreateEvent(event); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if event.Status != models.StatusConfirmed || event.Visibility != models.VisibilityPublic || event.Transparency != models.TransparencyOpaque {
+		t.Errorf("defaults = %s/%s/%s", event.Status, event.Visibility, event.Transparency)
+	}
+	if event.Color != "#a1b2c3" {
+		t.Errorf("color = %q, want #a1b2c3", event.Color)
+	}
+	if len(event.Attendees) != 2 {
+		t.Errorf("attendees = %q, want duplicates dropped", event.Attendees)
+	}
+
+	tests := []struct {
+		name  string
+		event *models.Event
+		field string
+	}{
+		{name: "long title", event: &models.Event{Event: strings.Repeat("a", 256)}, field: "event"},
+		{name: "long description", event: &models.Event{Event: "event", Description: strings.Repeat("a", 10001)}, field: "description"},
+		{name: "long location", event: &models.Event{Event: "event", Location: strings.Repeat("a", 1025)}, field: "location"},
+		{name: "bad url", event: &models.Event{Event: "event", URL: "ftp://example.com"}, field: "url"},
+		{name: "bad color", event: &models.Event{Event: "event", Color: "red"}, field: "color"},
+		{name: "bad status", event: &models.Event{Event: "event", Status: "maybe"}, field: "status"},
+		{name: "bad visibility", event: &models.Event{Event: "event", Visibility: "friends"}, field: "visibility"},
+		{name: "bad transparency", event: &models.Event{Event: "event", Transparency: "free"}, field: "transparency"},
+		{name: "end without start", event: &models.Event{Event: "event", EndTime: "11:00"}, field: "end_time"},
+		{name: "end before start", event: &models.Event{Event: "event", StartTime: "11:00", EndTime: "10:30"}, field: "end_time"},
+		{name: "bad attendee", event: &models.Event{Event: "event", Attendees: []string{"bob@"}}, field: "attendees"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.event.UserID = "1"
+			tt.event.Date = "2025-09-29"
+			_, err := srv.CreateEvent(tt.event)
+			var validationErr *errors.ValidationError
+			if !errors1.As(err, &validationErr) || validationErr.Field != tt.field {
+				t.Errorf("error = %v, want validation error on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestEvent_ViewFor(t *testing.T) {
+	event := &models.Event{
+		UserID:      "1",
+		EventID:     "e",
+		Date:        "2025-09-29",
+		StartTime:   "10:00",
+		Event:       "doctor",
+		Description: "room 5",
+		Visibility:  models.VisibilityBusy,
+	}
+	if view := event.ViewFor("1"); view != event {
+		t.Errorf("owner view = %+v, want the event itself", view)
+	}
+	view := event.ViewFor("2")
+	if view == nil || view.Event != models.BusyTitle || view.Description != "" || view.StartTime != "10:00" {
+		t.Errorf("busy view = %+v", view)
+	}
+
+	event.Transparency = models.TransparencyTransparent
+	if view := event.ViewFor("2"); view != nil {
+		t.Errorf("transparent busy view = %+v, want nil", view)
+	}
+	if view := event.ViewFor("1"); view != event {
+		t.Errorf("transparent owner view = %+v, want the event itself", view)
+	}
+
+	event.Visibility = models.VisibilityPrivate
+	if view := event.ViewFor("2"); view != nil {
+		t.Errorf("private view = %+v, want nil", view)
+	}
+
+	public := *event
+	public.Visibility = models.VisibilityPublic
+	change := &models.DomainEvent{Type: models.EventUpdated, UserID: "1", EventID: "e", Before: &public, After: event}
+	if view := change.ViewFor("2"); view == nil || view.Type != models.EventDeleted || view.After != nil {
+		t.Errorf("change hiding the event = %+v, want a deletion", view)
+	}
+	change.Before = event
+	if view := change.ViewFor("2"); view != nil {
+		t.Errorf("change of a private event = %+v, want nil", view)
+	}
+}
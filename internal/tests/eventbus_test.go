@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"Calendar/internal/eventbus"
+	"Calendar/internal/models"
+	"context"
+	errors1 "errors"
+	"testing"
+)
+
+func TestInMemoryBus_Publish(t *testing.T) {
+	bus := eventbus.NewInMemoryBus()
+	var first, second []int64
+	bus.Subscribe("first", func(ctx context.Context, event *models.DomainEvent) error {
+		first = append(first, event.ID)
+		return nil
+	})
+	unsubscribe := bus.Subscribe("second", func(ctx context.Context, event *models.DomainEvent) error {
+		second = append(second, event.ID)
+		return nil
+	})
+
+	if err := bus.Publish(context.Background(), &models.DomainEvent{ID: 1, Type: models.EventCreated}); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	unsubscribe()
+	if err := bus.Publish(context.Background(), &models.DomainEvent{ID: 2, Type: models.EventDeleted}); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+
+	if len(first) != 2 || first[0] != 1 || first[1] != 2 {
+		t.Errorf("first subscriber got %v, want [1 2]", first)
+	}
+	if len(second) != 1 || second[0] != 1 {
+		t.Errorf("second subscriber got %v, want [1]", second)
+	}
+}
+
+func TestInMemoryBus_PublishError(t *testing.T) {
+	bus := eventbus.NewInMemoryBus()
+	errFailed := errors1.New("failed")
+	called := false
+	bus.Subscribe("failing", func(ctx context.Context, event *models.DomainEvent) error {
+		return errFailed
+	})
+	bus.Subscribe("healthy", func(ctx context.Context, event *models.DomainEvent) error {
+		called = true
+		return nil
+	})
+
+	err := bus.Publish(context.Background(), &models.DomainEvent{ID: 1})
+	if !errors1.Is(err, errFailed) {
+		t.Errorf("error = %v, want %v", err, errFailed)
+	}
+	if !called {
+		t.Errorf("healthy subscriber was not called")
+	}
+}
+
+func TestSubscription_FilterAndLag(t *testing.T) {
+	bus := eventbus.NewInMemoryBus()
+	sub := eventbus.NewSubscription(bus, "test", 2, func(event *models.DomainEvent) bool {
+		return event.UserID == "1"
+	})
+	defer sub.Close()
+
+	ctx := context.Background()
+	_ = bus.Publish(ctx, &models.DomainEvent{ID: 1, UserID: "1"})
+	_ = bus.Publish(ctx, &models.DomainEvent{ID: 2, UserID: "2"})
+	_ = bus.Publish(ctx, &models.DomainEvent{ID: 3, UserID: "1"})
+
+	select {
+	case <-sub.Lagged:
+		t.Fatalf("subscription lagged before its buffer was full")
+	default:
+	}
+	if got := (<-sub.C).ID; got != 1 {
+		t.Errorf("first event id = %d, want 1", got)
+	}
+	if got := (<-sub.C).ID; got != 3 {
+		t.Errorf("second event id = %d, want 3", got)
+	}
+
+	for id := int64(4); id <= 7; id++ {
+		_ = bus.Publish(ctx, &models.DomainEvent{ID: id, UserID: "1"})
+	}
+	select {
+	case <-sub.Lagged:
+	default:
+		t.Errorf("subscription did not report lag after overflowing its buffer")
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/service"
+	"context"
+	"encoding/json"
+	errors1 "errors"
+	"net/http"
+	"testing"
+)
+
+func TestCalendarService_GetEvent(t *testing.T) {
+	repo := &StoredRepository{event: models.Event{
+		UserID:      "1",
+		EventID:     "e1",
+		Date:        "2025-09-29",
+		StartTime:   "10:00",
+		Event:       "doctor",
+		Description: "room 5",
+		Visibility:  models.VisibilityBusy,
+		Version:     3,
+	}}
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+
+	event, err := srv.GetEvent("e1", "1")
+	if err != nil || event.Event != "doctor" {
+		t.Fatalf("owner view = %+v, %v", event, err)
+	}
+	event, err = srv.GetEvent("e1", "2")
+	if err != nil || event.Event != models.BusyTitle || event.Description != "" || event.Version != 3 {
+		t.Errorf("busy view = %+v, %v", event, err)
+	}
+
+	repo.event.Visibility = models.VisibilityPrivate
+	tests := []struct {
+		name    string
+		eventID string
+	}{
+		{name: "private event", eventID: "e1"},
+		{name: "missing event", eventID: "e2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.GetEvent(tt.eventID, "2")
+			var notFoundErr *errors.NotFoundError
+			if !errors1.As(err, &notFoundErr) || notFoundErr.ID != tt.eventID {
+				t.Errorf("error = %v, want event %s not found", err, tt.eventID)
+			}
+		})
+	}
+}
+
+func TestAPI_GetEvent(t *testing.T) {
+	repo := &StoredRepository{event: models.Event{UserID: "1", EventID: "e1", Date: "2025-09-29", Event: "standup", Version: 2}}
+	router := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo))
+
+	rec := serve(router, http.MethodGet, "/api/v1/event?event_id=e1&user_id=2", "", nil)
+	var body struct {
+		Event models.Event `json:"event"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK || body.Event.Event != "standup" {
+		t.Errorf("get = %d %s, want 200 with the event", rec.Code, rec.Body)
+	}
+	if rec.Header().Get("ETag") != `"2"` {
+		t.Errorf("etag = %q, want \"2\"", rec.Header().Get("ETag"))
+	}
+
+	rec = serve(router, http.MethodGet, "/api/v2/events/e2", "", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing event = %d, want 404", rec.Code)
+	}
+
+	rec = serve(router, http.MethodPost, "/api/v1/update_event", `{"event_id": "e1", "user_id": "1", "event": "retro", "date": "2025-09-30", "version": 2}`, nil)
+	body.Event = models.Event{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK || body.Event.Event != "retro" || body.Event.Version != 3 {
+		t.Errorf("update = %d %s, want the stored event", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/service"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+// CountingRepository has one event per calendar and counts the repository
+// calls.
+type CountingRepository struct {
+	repository.CalendarRepositoryInterface
+	queries  atomic.Int32
+	settings atomic.Int32
+}
+
+func (m *CountingRepository) QueryEvents(query *models.EventQuery) ([]*models.Event, error) {
+	m.queries.Add(1)
+	var events []*models.Event
+	for _, calendarID := range query.CalendarIDs {
+		events = append(events, &models.Event{UserID: calendarID, EventID: "e" + calendarID, Date: "2025-09-29", Event: "standup", Visibility: models.VisibilityPublic})
+	}
+	return events, nil
+}
+
+func (m *CountingRepository) GetUsersSettings(userIDs []string) ([]*models.UserSettings, error) {
+	m.settings.Add(1)
+	settings := make([]*models.UserSettings, len(userIDs))
+	for i, userID := range userIDs {
+		settings[i] = &models.UserSettings{UserID: userID, WeekStart: models.WeekStartSunday}
+	}
+	return settings, nil
+}
+
+type graphResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func graphQuery(t *testing.T, router http.Handler, userID, query string, variables map[string]any) graphResponse {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": variables})
+	rec := serve(router, http.MethodPost, "/api/v2/graphql?user_id="+userID, string(body), nil)
+	var resp graphResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("graphql = %d %s", rec.Code, rec.Body)
+	}
+	return resp
+}
+
+func TestGraphQL_Batching(t *testing.T) {
+	repo := &CountingRepository{}
+	router := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo))
+
+	resp := graphQuery(t, router, "1", `{
+		calendars(ids: ["1", "2", "3", "4", "5"]) {
+			id
+			owner { weekStart }
+			events(from: "2025-09-01", to: "2025-09-30") {
+				id
+				title
+				calendar { owner { weekStart } }
+			}
+		}
+	}`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("errors = %+v", resp.Errors)
+	}
+	var data struct {
+		Calendars []struct {
+			ID     string
+			Owner  struct{ WeekStart string }
+			Events []struct {
+				ID    string
+				Title string
+			}
+		}
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Calendars) != 5 {
+		t.Fatalf("calendars = %+v", data.Calendars)
+	}
+	for _, calendar := range data.Calendars {
+		if calendar.Owner.WeekStart != models.WeekStartSunday || len(calendar.Events) != 1 || calendar.Events[0].ID != "e"+calendar.ID {
+			t.Errorf("calendar = %+v", calendar)
+		}
+	}
+	if queries, settings := repo.queries.Load(), repo.settings.Load(); queries != 1 || settings != 1 {
+		t.Errorf("repository calls = %d queries, %d settings, want one of each", queries, settings)
+	}
+}
+
+func TestGraphQL_Mutations(t *testing.T) {
+	repo := &StoredRepository{event: models.Event{
+		UserID:      "1",
+		EventID:     "e1",
+		Date:        "2025-09-29",
+		Event:       "standup",
+		Description: "daily",
+		Visibility:  models.VisibilityPrivate,
+		Reminders:   []*models.Reminder{{OffsetMinutes: 15, Channel: models.ChannelLog}},
+		Version:     2,
+	}}
+	router := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo))
+
+	resp := graphQuery(t, router, "1", `mutation($version: Int!) {
+		updateEvent(id: "e1", version: $version, input: {title: "retro", description: null}) { title description version }
+	}`, map[string]any{"version": 2})
+	if len(resp.Errors) > 0 || string(resp.Data) != `{"updateEvent":{"title":"retro","description":null,"version":3}}` {
+		t.Errorf("update = %s %+v", resp.Data, resp.Errors)
+	}
+	if len(repo.event.Reminders) != 1 {
+		t.Errorf("reminders = %v, want them kept", repo.event.Reminders)
+	}
+
+	resp = graphQuery(t, router, "2", `{ event(id: "e1") { title } }`, nil)
+	if len(resp.Errors) > 0 || string(resp.Data) != `{"event":null}` {
+		t.Errorf("private event = %s %+v, want null", resp.Data, resp.Errors)
+	}
+
+	resp = graphQuery(t, router, "1", `mutation { updateEvent(id: "e1", version: 2, input: {title: "planning"}) { version } }`, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "precondition_failed" || resp.Errors[0].Extensions["version"] != "3" {
+		t.Errorf("stale update = %+v, want precondition_failed with version 3", resp.Errors)
+	}
+
+	resp = graphQuery(t, router, "1", `mutation { createEvent(input: {date: "2025-09-29", title: ""}) { id } }`, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "validation_error" || resp.Errors[0].Extensions["field"] != "event" {
+		t.Errorf("invalid create = %+v, want validation_error on event", resp.Errors)
+	}
+}
+
+// SpreadRepository has 600 events in calendar 1 and one in calendar 2 after
+// them, and records the calendars of every query.
+type SpreadRepository struct {
+	repository.CalendarRepositoryInterface
+	queried [][]string
+}
+
+func (m *SpreadRepository) QueryEvents(query *models.EventQuery) ([]*models.Event, error) {
+	m.queried = append(m.queried, query.CalendarIDs)
+	var all []*models.Event
+	for i := range 600 {
+		all = append(all, &models.Event{UserID: "1", EventID: fmt.Sprintf("a%03d", i), Date: "2025-09-01", Event: "standup", Visibility: models.VisibilityPublic})
+	}
+	all = append(all, &models.Event{UserID: "2", EventID: "b", Date: "2025-09-30", Event: "retro", Visibility: models.VisibilityPublic})
+	var events []*models.Event
+	for _, event := range all {
+		after := query.After == nil || event.Date > query.After.Date || event.Date == query.After.Date && event.EventID > query.After.EventID
+		if after && slices.Contains(query.CalendarIDs, event.UserID) && len(events) < query.Limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func TestGraphQL_EventsLimit(t *testing.T) {
+	repo := &SpreadRepository{}
+	router := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo))
+
+	resp := graphQuery(t, router, "1", `{
+		calendars(ids: ["1", "2"]) { id events(from: "2025-09-01", to: "2025-09-30", first: 2) { id } }
+	}`, nil)
+	var data struct {
+		Calendars []struct {
+			ID     string
+			Events []struct{ ID string }
+		}
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil || len(resp.Errors) > 0 {
+		t.Fatalf("data = %s, errors = %+v", resp.Data, resp.Errors)
+	}
+	if len(data.Calendars) != 2 || len(data.Calendars[0].Events) != 2 || len(data.Calendars[1].Events) != 1 {
+		t.Errorf("calendars = %+v, want 2 events of calendar 1 and 1 of calendar 2", data.Calendars)
+	}
+	if len(repo.queried) != 2 || !slices.Equal(repo.queried[1], []string{"2"}) {
+		t.Errorf("queried calendars = %v, want the full calendar left out of the second page", repo.queried)
+	}
+
+	resp = graphQuery(t, router, "1", `{ calendars(ids: ["1"]) { events(from: "2025-09-01", to: "2025-09-30", first: 501) { id } } }`, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Extensions["field"] != "first" {
+		t.Errorf("errors = %+v, want validation_error on first", resp.Errors)
+	}
+}
@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"Calendar/internal/config"
+	"Calendar/internal/errors"
+	"Calendar/internal/eventbus"
+	"Calendar/internal/models"
+	"Calendar/internal/rpc"
+	"Calendar/internal/rpc/calendarpb"
+	"Calendar/internal/service"
+	"Calendar/pkg/logger"
+	"context"
+	errors1 "errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+// FakeChangeService replays a fixed change log and publishes on an in-memory
+// bus.
+type FakeChangeService struct {
+	bus     *eventbus.InMemoryBus
+	history []*models.DomainEvent
+}
+
+func (f *FakeChangeService) GetChangesSince(userID string, lastEventID string) ([]*models.DomainEvent, error) {
+	if lastEventID == "" {
+		return nil, nil
+	}
+	return f.history, nil
+}
+
+func (f *FakeChangeService) Subscribe(name string, buffer int, filter func(event *models.DomainEvent) bool) *eventbus.Subscription {
+	return eventbus.NewSubscription(f.bus, name, buffer, filter)
+}
+
+func newTestGRPCClient(t *testing.T, srv service.CalendarServiceInterface, changes service.ChangeServiceInterface) calendarpb.CalendarServiceClient {
+	t.Helper()
+	ctx, err := logger.New(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	server := rpc.NewCalendarServer(ctx, &config.Config{}, srv, changes)
+	go server.Serve(lis)
+	t.Cleanup(func() { server.Shutdown(context.Background()) })
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return calendarpb.NewCalendarServiceClient(conn)
+}
+
+func TestGRPC_Events(t *testing.T) {
+	repo := &StoredRepository{event: models.Event{UserID: "1", EventID: "e1", Date: "2025-09-29", Event: "standup", Visibility: models.VisibilityPrivate, Version: 2}}
+	client := newTestGRPCClient(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo), nil)
+	ctx := context.Background()
+
+	event, err := client.GetEvent(ctx, &calendarpb.GetEventRequest{EventId: "e1", UserId: "1"})
+	if err != nil || event.GetTitle() != "standup" || event.GetVersion() != 2 {
+		t.Fatalf("get = %v, %v", event, err)
+	}
+	event.Title = "retro"
+	event, err = client.UpdateEvent(ctx, &calendarpb.UpdateEventRequest{Event: event})
+	if err != nil || event.GetTitle() != "retro" || event.GetVersion() != 3 {
+		t.Errorf("update = %v, %v", event, err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{name: "private event", call: func() error {
+			_, err := client.GetEvent(ctx, &calendarpb.GetEventRequest{EventId: "e1", UserId: "2"})
+			return err
+		}, code: codes.NotFound},
+		{name: "invalid event", call: func() error {
+			_, err := client.CreateEvent(ctx, &calendarpb.CreateEventRequest{Event: &calendarpb.Event{UserId: "1", Date: "2025-09-29"}})
+			return err
+		}, code: codes.InvalidArgument},
+		{name: "stale version", call: func() error {
+			_, err := client.UpdateEvent(ctx, &calendarpb.UpdateEventRequest{Event: &calendarpb.Event{EventId: "e1", UserId: "1", Title: "standup", Date: "2025-09-29", Version: 2}})
+			return err
+		}, code: codes.Aborted},
+		{name: "missing version", call: func() error {
+			_, err := client.DeleteEvent(ctx, &calendarpb.DeleteEventRequest{EventId: "e1"})
+			return err
+		}, code: codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(tt.call()); code != tt.code {
+				t.Errorf("code = %s, want %s", code, tt.code)
+			}
+		})
+	}
+
+	_, err = client.CreateEvent(ctx, &calendarpb.CreateEventRequest{Event: &calendarpb.Event{UserId: "1", Date: "2025-09-29"}})
+	var badRequest *errdetails.BadRequest
+	for _, detail := range status.Convert(err).Details() {
+		if d, ok := detail.(*errdetails.BadRequest); ok {
+			badRequest = d
+		}
+	}
+	if badRequest == nil || badRequest.GetFieldViolations()[0].GetField() != "event" {
+		t.Errorf("details = %v, want a field violation on event", status.Convert(err).Details())
+	}
+}
+
+func TestGRPC_WatchEvents(t *testing.T) {
+	changes := &FakeChangeService{
+		bus:     eventbus.NewInMemoryBus(),
+		history: []*models.DomainEvent{{ID: 10, Seq: 5, Type: models.EventCreated, EventID: "e1", UserID: "1"}},
+	}
+	client := newTestGRPCClient(t, &FakeCalendarService{}, changes)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchEvents(ctx, &calendarpb.WatchEventsRequest{UserId: "1", AfterId: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, err := stream.Recv()
+	if err != nil || change.GetId() != 5 {
+		t.Fatalf("history = %v, %v", change, err)
+	}
+	// The subscription exists once the history was sent.
+	for _, event := range []*models.DomainEvent{
+		{ID: 10, Seq: 5, Type: models.EventCreated, EventID: "e1", UserID: "1"},
+		{ID: 11, Seq: 1, Type: models.EventCreated, EventID: "e2", UserID: "2"},
+		{ID: 8, Seq: 6, Type: models.EventUpdated, EventID: "e1", UserID: "1", After: &models.Event{EventID: "e1", Event: "retro"}},
+	} {
+		changes.bus.Publish(ctx, event)
+	}
+	change, err = stream.Recv()
+	if err != nil || change.GetId() != 6 || change.GetAfter().GetTitle() != "retro" {
+		t.Errorf("live change = %v, %v, want 6 with duplicates and other users skipped", change, err)
+	}
+}
+
+func TestGRPC_Actor(t *testing.T) {
+	repo := newHistoryRepository()
+	client := newTestGRPCClient(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo), nil)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "user-id", "2")
+
+	event, err := client.CreateEvent(ctx, &calendarpb.CreateEventRequest{Event: &calendarpb.Event{UserId: "1", Date: "2025-09-29", Title: "retro"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := repo.history[event.GetEventId()]; len(entries) != 1 || entries[0].Actor != "2" {
+		t.Errorf("history = %v, want a revision by actor 2", entries)
+	}
+}
+
+// FailingCalendarService fails every delete with err.
+type FailingCalendarService struct {
+	service.CalendarServiceInterface
+	err error
+}
+
+func (f *FailingCalendarService) DeleteEvent(eventID string, version int64) error {
+	return f.err
+}
+
+func TestGRPC_ErrorCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{name: "conflict", err: &errors.ConflictError{Message: "key reused"}, code: codes.AlreadyExists},
+		{name: "aborted", err: &errors.AbortedError{Index: 1}, code: codes.Aborted},
+		{name: "quota", err: &errors.QuotaExceededError{Resource: "events", Limit: 1}, code: codes.ResourceExhausted},
+		{name: "unknown", err: errors1.New("boom"), code: codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestGRPCClient(t, &FailingCalendarService{err: tt.err}, nil)
+			_, err := client.DeleteEvent(context.Background(), &calendarpb.DeleteEventRequest{EventId: "e1", Version: 1})
+			if code := status.Code(err); code != tt.code {
+				t.Errorf("code = %s, want %s", code, tt.code)
+			}
+		})
+	}
+}
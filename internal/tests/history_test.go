@@ -0,0 +1,300 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/service"
+	"context"
+	"encoding/json"
+	errors1 "errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// HistoryRepository records the creates, updates and reverts of a
+// MemoryRepository like the history table, attributed to its actor.
+type HistoryRepository struct {
+	*MemoryRepository
+	history map[string][]*models.HistoryEntry
+	actor   string
+}
+
+func newHistoryRepository() *HistoryRepository {
+	return &HistoryRepository{
+		MemoryRepository: newMemoryRepository(storedEvents()...),
+		history:          make(map[string][]*models.HistoryEntry),
+	}
+}
+
+func (h *HistoryRepository) WithActor(actorID string) repository.CalendarRepositoryInterface {
+	scoped := *h
+	scoped.actor = actorID
+	return &scoped
+}
+
+func (h *HistoryRepository) record(action string, before, after *models.Event, revertedTo int) {
+	actor := h.actor
+	if actor == "" {
+		actor = after.UserID
+	}
+	var id int64 = 1
+	for _, entries := range h.history {
+		id += int64(len(entries))
+	}
+	snapshot := *after
+	h.history[after.EventID] = append(h.history[after.EventID], &models.HistoryEntry{
+		ID:         id,
+		EventID:    after.EventID,
+		UserID:     after.UserID,
+		Revision:   len(h.history[after.EventID]) + 1,
+		Version:    after.Version,
+		Action:     action,
+		Actor:      actor,
+		ChangedAt:  time.Date(2025, time.October, 1, 12, int(id), 0, 0, time.UTC),
+		Changes:    models.DiffEvents(before, after),
+		RevertedTo: revertedTo,
+		Snapshot:   &snapshot,
+	})
+}
+
+func (h *HistoryRepository) CreateEvent(event *models.Event) error {
+	if err := h.MemoryRepository.CreateEvent(event); err != nil {
+		return err
+	}
+	h.record(models.HistoryCreated, nil, event, 0)
+	return nil
+}
+
+func (h *HistoryRepository) UpdateEvent(event *models.Event) error {
+	return h.RevertEvent(event, 0)
+}
+
+func (h *HistoryRepository) RevertEvent(event *models.Event, revision int) error {
+	before := h.events[event.EventID]
+	if err := h.MemoryRepository.UpdateEvent(event); err != nil {
+		return err
+	}
+	action := models.HistoryUpdated
+	if revision > 0 {
+		action = models.HistoryReverted
+	}
+	h.record(action, &before, event, revision)
+	return nil
+}
+
+func (h *HistoryRepository) GetHistory(eventID string) ([]*models.HistoryEntry, error) {
+	return h.history[eventID], nil
+}
+
+func (h *HistoryRepository) Audit(query *models.AuditQuery) ([]*models.HistoryEntry, error) {
+	var entries []*models.HistoryEntry
+	for _, history := range h.history {
+		for _, entry := range history {
+			if (query.Actor == "" || entry.Actor == query.Actor) &&
+				(query.BeforeID == 0 || entry.ID < query.BeforeID) &&
+				(query.From.IsZero() || !entry.ChangedAt.Before(query.From)) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	slices.SortFunc(entries, func(a, b *models.HistoryEntry) int { return int(b.ID - a.ID) })
+	return entries[:min(len(entries), query.Limit)], nil
+}
+
+func TestDiffEvents(t *testing.T) {
+	event := &models.Event{
+		UserID:    "1",
+		EventID:   "e1",
+		Date:      "2025-09-29",
+		Event:     "standup",
+		Reminders: []*models.Reminder{{ReminderID: "r1", EventID: "e1", OffsetMinutes: 10, Channel: models.ChannelLog}},
+		Version:   1,
+	}
+	changed := *event
+	changed.Event = "daily standup"
+	changed.Location = "room 5"
+	changed.Reminders = []*models.Reminder{{ReminderID: "r2", EventID: "e1", OffsetMinutes: 10, Channel: models.ChannelLog}}
+	changed.Version = 2
+
+	tests := []struct {
+		name   string
+		before *models.Event
+		after  *models.Event
+		want   map[string]models.FieldChange
+	}{
+		{
+			name:   "update",
+			before: event,
+			after:  &changed,
+			want: map[string]models.FieldChange{
+				"event":    {From: "standup", To: "daily standup"},
+				"location": {From: nil, To: "room 5"},
+			},
+		},
+		{
+			name:   "unchanged",
+			before: event,
+			after:  event,
+			want:   map[string]models.FieldChange{},
+		},
+		{
+			name:  "create",
+			after: &models.Event{UserID: "1", EventID: "e1", Date: "2025-09-29", Event: "standup"},
+			want: map[string]models.FieldChange{
+				"user_id":  {From: nil, To: "1"},
+				"event_id": {From: nil, To: "e1"},
+				"date":     {From: nil, To: "2025-09-29"},
+				"event":    {From: nil, To: "standup"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := models.DiffEvents(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarService_History(t *testing.T) {
+	repo := newHistoryRepository()
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+
+	event := &models.Event{UserID: "1", Date: "2025-09-29", Event: "retro"}
+	id, err := service.WithActor(srv, "2").CreateEvent(event)
+	if err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	event.Event = "sprint retro"
+	if err := srv.UpdateEvent(event); err != nil {
+		t.Fatalf("update error = %v", err)
+	}
+
+	history, err := srv.GetHistory(id, "1")
+	if err != nil || len(history) != 2 {
+		t.Fatalf("history = %v, %v", history, err)
+	}
+	if history[0].Action != models.HistoryCreated || history[0].Actor != "2" {
+		t.Errorf("first revision = %+v, want created by 2", history[0])
+	}
+	want := map[string]models.FieldChange{"event": {From: "retro", To: "sprint retro"}}
+	if history[1].Actor != "1" || !reflect.DeepEqual(history[1].Changes, want) {
+		t.Errorf("second revision = %+v, want %v by the owner", history[1], want)
+	}
+	var notFound *errors.NotFoundError
+	if _, err := srv.GetHistory(id, "2"); !errors1.As(err, &notFound) {
+		t.Errorf("history of another user = %v, want not found", err)
+	}
+
+	reverted, err := srv.RevertEvent(id, "1", 1, 2)
+	if err != nil || reverted.Event != "retro" || reverted.Version != 3 {
+		t.Fatalf("revert = %+v, %v, want retro at version 3", reverted, err)
+	}
+	if last := repo.history[id][2]; last.Action != models.HistoryReverted || last.RevertedTo != 1 {
+		t.Errorf("revert revision = %+v", last)
+	}
+	var stale *errors.PreconditionFailedError
+	if _, err := srv.RevertEvent(id, "1", 1, 2); !errors1.As(err, &stale) {
+		t.Errorf("stale revert = %v, want precondition failed", err)
+	}
+	var required *errors.PreconditionRequiredError
+	if _, err := srv.RevertEvent(id, "1", 1, 0); !errors1.As(err, &required) {
+		t.Errorf("revert without version = %v, want precondition required", err)
+	}
+	if _, err := srv.RevertEvent(id, "1", 9, 3); !errors1.As(err, &notFound) {
+		t.Errorf("revert to missing revision = %v, want not found", err)
+	}
+	if _, err := srv.RevertEvent(id, "2", 1, 3); !errors1.As(err, &notFound) {
+		t.Errorf("revert by another user = %v, want not found", err)
+	}
+
+	page, err := srv.Audit(&models.AuditParams{Actor: "1", Limit: "1"})
+	if err != nil || len(page.Entries) != 1 || page.Entries[0].Action != models.HistoryReverted || page.NextCursor == "" {
+		t.Fatalf("first audit page = %+v, %v", page, err)
+	}
+	page, err = srv.Audit(&models.AuditParams{Actor: "1", Cursor: page.NextCursor})
+	if err != nil || len(page.Entries) != 1 || page.Entries[0].Action != models.HistoryUpdated || page.NextCursor != "" {
+		t.Errorf("second audit page = %+v, %v", page, err)
+	}
+
+	tests := []struct {
+		name  string
+		call  func() error
+		field string
+	}{
+		{name: "history without event", call: func() error { _, err := srv.GetHistory("", "1"); return err }, field: "event_id"},
+		{name: "history without user", call: func() error { _, err := srv.GetHistory(id, ""); return err }, field: "user_id"},
+		{name: "revert without user", call: func() error { _, err := srv.RevertEvent(id, "", 1, 3); return err }, field: "user_id"},
+		{name: "revert revision zero", call: func() error { _, err := srv.RevertEvent(id, "1", 0, 3); return err }, field: "revision"},
+		{name: "audit bad from", call: func() error { _, err := srv.Audit(&models.AuditParams{From: "2025-10-01"}); return err }, field: "from"},
+		{name: "audit to before from", call: func() error {
+			_, err := srv.Audit(&models.AuditParams{From: "2025-10-02T00:00:00Z", To: "2025-10-01T00:00:00Z"})
+			return err
+		}, field: "to"},
+		{name: "audit bad cursor", call: func() error { _, err := srv.Audit(&models.AuditParams{Cursor: "abc"}); return err }, field: "cursor"},
+		{name: "audit limit too big", call: func() error { _, err := srv.Audit(&models.AuditParams{Limit: "501"}); return err }, field: "limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !isValidationError(tt.field)(err) {
+				t.Errorf("error = %v, want validation error on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestAPI_History(t *testing.T) {
+	repo := newHistoryRepository()
+	router := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo))
+
+	rec := serve(router, http.MethodPost, "/api/v2/events?user_id=2", `{"user_id": "1", "date": "2025-09-29", "event": "retro"}`, nil)
+	var event models.Event
+	if err := json.Unmarshal(rec.Body.Bytes(), &event); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("create = %d %s", rec.Code, rec.Body)
+	}
+	rec = serve(router, http.MethodPut, "/api/v2/events/"+event.EventID, `{"user_id": "1", "date": "2025-09-30", "event": "retro"}`,
+		map[string]string{"If-Match": `"1"`})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(router, http.MethodGet, "/api/v2/events/"+event.EventID+"/history?user_id=1", "", nil)
+	var resp struct{ History []*models.HistoryEntry }
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK || len(resp.History) != 2 {
+		t.Fatalf("history = %d %s", rec.Code, rec.Body)
+	}
+	if resp.History[0].Actor != "2" || resp.History[1].Actor != "1" || resp.History[1].Changes["date"].To != "2025-09-30" {
+		t.Errorf("history = %s", rec.Body)
+	}
+
+	if rec := serve(router, http.MethodPost, "/api/v2/events/"+event.EventID+"/revert?user_id=2", `{"revision": 1}`, map[string]string{"If-Match": `"2"`}); rec.Code != http.StatusNotFound {
+		t.Errorf("revert by another user = %d, want 404", rec.Code)
+	}
+	rec = serve(router, http.MethodPost, "/api/v2/events/"+event.EventID+"/revert?user_id=1", `{"revision": 1}`, map[string]string{"If-Match": `"2"`})
+	if err := json.Unmarshal(rec.Body.Bytes(), &event); err != nil || rec.Code != http.StatusOK || event.Date != "2025-09-29" || rec.Header().Get("ETag") != `"3"` {
+		t.Fatalf("revert = %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, http.MethodPost, "/api/v2/events/"+event.EventID+"/revert?user_id=1", `{"revision": 1}`, nil); rec.Code != http.StatusPreconditionRequired {
+		t.Errorf("revert without version = %d, want 428", rec.Code)
+	}
+
+	admin := map[string]string{"X-API-Key": testAdminKey}
+	for key, status := range map[string]int{"": http.StatusUnauthorized, "user": http.StatusForbidden} {
+		if rec := serve(router, http.MethodGet, "/api/v2/admin/audit?actor=2", "", map[string]string{"X-API-Key": key}); rec.Code != status {
+			t.Errorf("audit with key %q = %d, want %d", key, rec.Code, status)
+		}
+	}
+	rec = serve(router, http.MethodGet, "/api/v2/admin/audit?actor=2", "", admin)
+	var page models.AuditPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil || rec.Code != http.StatusOK || len(page.Entries) != 1 {
+		t.Fatalf("audit = %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(router, http.MethodGet, "/api/v2/admin/audit?from=yesterday", "", admin); rec.Code != http.StatusBadRequest {
+		t.Errorf("audit with bad from = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"Calendar/internal/config"
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/service"
+	"Calendar/internal/transport"
+	"Calendar/pkg/logger"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testScope is the idempotency scope of requests sent by serve, which come
+// from the httptest remote address.
+const testScope = "ip:192.0.2.1"
+
+// scopedKey is an Idempotency-Key of a caller.
+type scopedKey struct {
+	scope, key string
+}
+
+// MemoryIdempotencyRepository keeps idempotency records in a map.
+type MemoryIdempotencyRepository struct {
+	records map[scopedKey]models.IdempotencyRecord
+}
+
+func (m *MemoryIdempotencyRepository) Claim(record *models.IdempotencyRecord, lease time.Duration) (*models.IdempotencyRecord, error) {
+	if stored, ok := m.records[scopedKey{record.Scope, record.Key}]; ok && stored.ExpiresAt.After(time.Now()) {
+		return &stored, nil
+	}
+	m.records[scopedKey{record.Scope, record.Key}] = *record
+	return nil, nil
+}
+
+func (m *MemoryIdempotencyRepository) Save(record *models.IdempotencyRecord) error {
+	stored := m.records[scopedKey{record.Scope, record.Key}]
+	stored.Status, stored.Header, stored.Body = record.Status, record.Header, record.Body
+	m.records[scopedKey{record.Scope, record.Key}] = stored
+	return nil
+}
+
+func (m *MemoryIdempotencyRepository) Release(scope string, key string) error {
+	delete(m.records, scopedKey{scope, key})
+	return nil
+}
+
+func (m *MemoryIdempotencyRepository) DeleteExpired(limit int) (int64, error) {
+	return 0, nil
+}
+
+// FlakyCalendarService fails the first event creation.
+type FlakyCalendarService struct {
+	service.CalendarServiceInterface
+	calls int
+}
+
+func (f *FlakyCalendarService) CreateEvent(event *models.Event) (string, error) {
+	f.calls++
+	if f.calls == 1 {
+		return "", &errors.BusinessError{Message: "connection reset"}
+	}
+	return f.CalendarServiceInterface.CreateEvent(event)
+}
+
+func newIdempotentRouter(t *testing.T, srv service.CalendarServiceInterface, repo *MemoryIdempotencyRepository) http.Handler {
+	t.Helper()
+	ctx, err := logger.New(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idempotency := service.NewIdempotencyService(ctx, service.IdempotencyConfig{TTL: time.Hour, Lease: time.Minute}, repo)
+	return transport.NewCalendarServer(ctx, &config.Config{}, srv, nil, nil, idempotency).Router()
+}
+
+func TestAPI_IdempotencyKey(t *testing.T) {
+	events := newMemoryRepository(storedEvents()...)
+	keys := &MemoryIdempotencyRepository{records: make(map[scopedKey]models.IdempotencyRecord)}
+	router := newIdempotentRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, events), keys)
+	create := `{"user_id": "1", "event": "retro", "date": "2025-09-29"}`
+	withKey := func(key string) map[string]string { return map[string]string{"Idempotency-Key": key} }
+
+	first := serve(router, http.MethodPost, "/api/v2/events", create, withKey("k1"))
+	retry := serve(router, http.MethodPost, "/api/v2/events", create, withKey("k1"))
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated {
+		t.Fatalf("create = %d, retry = %d", first.Code, retry.Code)
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get("Location") != first.Header().Get("Location") || retry.Header().Get("ETag") != `"1"` {
+		t.Errorf("retry = %s %v, want the first response %s", retry.Body, retry.Header(), first.Body)
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("want only the retry marked as replayed")
+	}
+	if len(events.events) != 3 {
+		t.Errorf("stored %d events, want one created", len(events.events))
+	}
+
+	if rec := serve(router, http.MethodPost, "/api/v2/events", create, nil); rec.Code != http.StatusCreated || len(events.events) != 4 {
+		t.Errorf("create without key = %d, want a new event", rec.Code)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		header map[string]string
+		status int
+		code   string
+	}{
+		{name: "different body", method: http.MethodPost, target: "/api/v2/events", body: `{"user_id": "1", "event": "planning", "date": "2025-09-29"}`, header: withKey("k1"), status: http.StatusConflict, code: "conflict"},
+		{name: "different route", method: http.MethodPost, target: "/api/v1/create_event", body: create, header: withKey("k1"), status: http.StatusConflict, code: "conflict"},
+		{name: "in progress", method: http.MethodDelete, target: "/api/v2/events/e2?version=1", header: withKey("running"), status: http.StatusConflict, code: "conflict"},
+		{name: "key too long", method: http.MethodDelete, target: "/api/v2/events/e2?version=1", header: withKey(strings.Repeat("k", 256)), status: http.StatusBadRequest, code: "validation_error"},
+	}
+	keys.records[scopedKey{testScope, "running"}] = models.IdempotencyRecord{Scope: testScope, Key: "running", ExpiresAt: time.Now().Add(time.Hour)}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, tt.method, tt.target, tt.body, tt.header)
+			var resp struct{ Error string }
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if rec.Code != tt.status || resp.Error != tt.code {
+				t.Errorf("status = %d %s, want %d %s", rec.Code, rec.Body, tt.status, tt.code)
+			}
+		})
+	}
+
+	// A failed precondition is replayed like a success.
+	stale := serve(router, http.MethodDelete, "/api/v2/events/e2?version=5", "", withKey("k2"))
+	delete(events.events, "e2")
+	retry = serve(router, http.MethodDelete, "/api/v2/events/e2?version=5", "", withKey("k2"))
+	if stale.Code != http.StatusPreconditionFailed || retry.Code != http.StatusPreconditionFailed || retry.Header().Get("ETag") != `"1"` {
+		t.Errorf("stale delete = %d, retry = %d %v", stale.Code, retry.Code, retry.Header())
+	}
+
+	// Expired keys are used again.
+	keys.records[scopedKey{testScope, "k1"}] = models.IdempotencyRecord{Scope: testScope, Key: "k1", Status: http.StatusCreated, ExpiresAt: time.Now().Add(-time.Second)}
+	if rec := serve(router, http.MethodPost, "/api/v2/events", create, withKey("k1")); rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("create with expired key = %d %v, want a new event", rec.Code, rec.Header())
+	}
+}
+
+func TestAPI_IdempotencyKeyAfterServerError(t *testing.T) {
+	flaky := &FlakyCalendarService{CalendarServiceInterface: service.NewCalendarService(context.Background(), service.QuotaConfig{}, newMemoryRepository())}
+	keys := &MemoryIdempotencyRepository{records: make(map[scopedKey]models.IdempotencyRecord)}
+	router := newIdempotentRouter(t, flaky, keys)
+	create := `{"user_id": "1", "event": "retro", "date": "2025-09-29"}`
+
+	if rec := serve(router, http.MethodPost, "/api/v2/events", create, map[string]string{"Idempotency-Key": "k1"}); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first create = %d, want 503", rec.Code)
+	}
+	if _, ok := keys.records[scopedKey{testScope, "k1"}]; ok {
+		t.Error("key kept after a server error")
+	}
+	rec := serve(router, http.MethodPost, "/api/v2/events", create, map[string]string{"Idempotency-Key": "k1"})
+	if rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "" || flaky.calls != 2 {
+		t.Errorf("retry = %d after %d calls, want the request run again", rec.Code, flaky.calls)
+	}
+	if keys.records[scopedKey{testScope, "k1"}].Status != http.StatusCreated {
+		t.Errorf("stored status = %d, want 201", keys.records[scopedKey{testScope, "k1"}].Status)
+	}
+}
+
+func TestAPI_IdempotencyKeyScope(t *testing.T) {
+	events := newMemoryRepository(storedEvents()...)
+	keys := &MemoryIdempotencyRepository{records: make(map[scopedKey]models.IdempotencyRecord)}
+	router := newIdempotentRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, events), keys)
+	create := `{"user_id": "1", "event": "retro", "date": "2025-09-29"}`
+
+	callers := []map[string]string{
+		{"Idempotency-Key": "k1"},
+		{"Idempotency-Key": "k1", "X-API-Key": "first"},
+		{"Idempotency-Key": "k1", "X-API-Key": "second"},
+	}
+	for _, header := range callers {
+		rec := serve(router, http.MethodPost, "/api/v2/events", create, header)
+		if rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("create with %v = %d %v, want a new event", header, rec.Code, rec.Header())
+		}
+	}
+	if other := serve(router, http.MethodPost, "/api/v2/events?user_id=2", `{"user_id": "1", "event": "planning", "date": "2025-09-29"}`, callers[0]); other.Code != http.StatusCreated {
+		t.Errorf("create by another user = %d %s, want the key unused", other.Code, other.Body)
+	}
+	if len(events.events) != 6 {
+		t.Errorf("stored %d events, want one per caller", len(events.events))
+	}
+	for scope := range keys.records {
+		if strings.Contains(scope.scope, "first") || strings.Contains(scope.scope, "second") {
+			t.Errorf("scope %q stores the raw API key", scope.scope)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"Calendar/internal/metrics"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/service"
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// repositoryCalls returns how many calls of method with outcome were observed.
+func repositoryCalls(method, outcome string) uint64 {
+	var m dto.Metric
+	if err := metrics.RepositoryDuration.WithLabelValues(method, outcome).(prometheus.Histogram).Write(&m); err != nil {
+		return 0
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestMeasuredRepository(t *testing.T) {
+	history := newHistoryRepository()
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repository.NewMeasuredRepository(history))
+	changes := func(action string) float64 {
+		return testutil.ToFloat64(metrics.EventChanges.WithLabelValues(action))
+	}
+	created, deleted := changes(models.HistoryCreated), changes(models.HistoryDeleted)
+	failedDeletes := repositoryCalls("DeleteEvent", "error")
+
+	event := &models.Event{UserID: "2", Date: "2025-09-29", Event: "retro"}
+	if _, err := service.WithActor(srv, "1").CreateEvent(event); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	if got := changes(models.HistoryCreated) - created; got != 1 {
+		t.Errorf("created changes = %v, want 1", got)
+	}
+	if entries := history.history[event.EventID]; len(entries) != 1 || entries[0].Actor != "1" {
+		t.Errorf("history = %v, want a revision by actor 1", entries)
+	}
+
+	if err := srv.DeleteEvent("missing", 1); err == nil {
+		t.Fatal("delete of a missing event succeeded")
+	}
+	if got := changes(models.HistoryDeleted) - deleted; got != 0 {
+		t.Errorf("deleted changes = %v, want 0 for a failed delete", got)
+	}
+	if got := repositoryCalls("DeleteEvent", "error") - failedDeletes; got != 1 {
+		t.Errorf("failed DeleteEvent calls = %d, want 1", got)
+	}
+}
+
+func TestAPI_Metrics(t *testing.T) {
+	router := newTestRouter(t, &FakeCalendarService{})
+	serve(router, http.MethodGet, "/openapi.json", "", nil)
+	serve(router, http.MethodGet, "/no_such_route", "", nil)
+
+	rec := serve(router, http.MethodGet, "/metrics", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	for _, want := range []string{
+		`calendar_http_requests_total{method="GET",route="/openapi.json",status="200"}`,
+		`calendar_http_requests_total{method="GET",route="unmatched",status="404"}`,
+		`calendar_http_request_duration_seconds_bucket{method="GET",route="/openapi.json",status="200"`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
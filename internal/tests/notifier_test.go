@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"Calendar/internal/models"
+	"Calendar/internal/notifier"
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer accepts a single message and sends the received DATA section
+// to the returned channel.
+func fakeSMTPServer(t *testing.T) (string, string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		write("220 localhost ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					received <- data.String()
+					write("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				write("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				inData = true
+				write("354 End data with <CR><LF>.<CR><LF>")
+			case strings.HasPrefix(cmd, "QUIT"):
+				write("221 Bye")
+				return
+			default:
+				write("250 OK")
+			}
+		}
+	}()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	return host, port, received
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	host, port, received := fakeSMTPServer(t)
+	n := notifier.NewSMTPNotifier(notifier.SMTPConfig{
+		Host: host,
+		Port: port,
+		From: "calendar@localhost",
+	})
+
+	err := n.Notify(context.Background(), &models.Notification{
+		DeliveryID: "j1",
+		EventID:    "1",
+		UserID:     "1",
+		Event:      "standup\r\nBcc: someone@example.com",
+		Date:       "2025-09-29",
+		StartTime:  "10:00",
+		Channel:    models.ChannelEmail,
+		Target:     "user@example.com",
+	})
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+
+	msg := <-received
+	if !strings.Contains(msg, "To: user@example.com\r\n") {
+		t.Errorf("message has no recipient header: %q", msg)
+	}
+	if !strings.Contains(msg, "Message-ID: <j1@"+host+">\r\n") {
+		t.Errorf("message has no delivery message ID: %q", msg)
+	}
+	if !strings.Contains(msg, "starts at 2025-09-29 10:00") {
+		t.Errorf("message has no event time: %q", msg)
+	}
+	headers, _, _ := strings.Cut(msg, "\r\n\r\n")
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("event title injected a header: %q", msg)
+	}
+}
+
+func TestSMTPNotifier_HungServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	// The server accepts connections and never greets.
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	notification := &models.Notification{Channel: models.ChannelEmail, Target: "user@example.com"}
+
+	n := notifier.NewSMTPNotifier(notifier.SMTPConfig{Host: host, Port: port, Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	if err := n.Notify(context.Background(), notification); err == nil || time.Since(start) > time.Second {
+		t.Errorf("notify = %v after %v, want a timeout", err, time.Since(start))
+	}
+
+	n = notifier.NewSMTPNotifier(notifier.SMTPConfig{Host: host, Port: port, Timeout: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start = time.Now()
+	if err := n.Notify(ctx, notification); err == nil || time.Since(start) > time.Second {
+		t.Errorf("notify = %v after %v, want it cancelled", err, time.Since(start))
+	}
+}
+
+func TestDispatcher_UnknownChannel(t *testing.T) {
+	d := notifier.NewDispatcher(map[string]notifier.Notifier{
+		models.ChannelLog: notifier.NewLogNotifier(),
+	})
+	err := d.Notify(context.Background(), &models.Notification{Channel: "sms"})
+	if err == nil {
+		t.Errorf("error = nil, want error for unknown channel")
+	}
+}
@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"Calendar/internal/models"
+	"Calendar/internal/service"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// openAPISchema is the subset of an OpenAPI 3.0 schema object the document
+// uses.
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref"`
+	Type                 string                    `json:"type"`
+	Nullable             bool                      `json:"nullable"`
+	Enum                 []any                     `json:"enum"`
+	Pattern              string                    `json:"pattern"`
+	MaxLength            *int                      `json:"maxLength"`
+	Properties           map[string]*openAPISchema `json:"properties"`
+	Required             []string                  `json:"required"`
+	AdditionalProperties json.RawMessage           `json:"additionalProperties"`
+	Items                *openAPISchema            `json:"items"`
+}
+
+type openAPIDocument struct {
+	Paths map[string]map[string]struct {
+		Responses map[string]struct {
+			Content map[string]struct {
+				Schema *openAPISchema `json:"schema"`
+			} `json:"content"`
+		} `json:"responses"`
+	} `json:"paths"`
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+// validate returns the places where value doesn't match schema.
+func (d *openAPIDocument) validate(schema *openAPISchema, value any, at string) []string {
+	if schema.Ref != "" {
+		return d.validate(d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")], value, at)
+	}
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return []string{at + ": null"}
+	}
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, v := range schema.Enum {
+			found = found || v == value
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: %v not in %v", at, value, schema.Enum)}
+		}
+	}
+	switch schema.Type {
+	case "":
+		return nil
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v is not a string", at, value)}
+		}
+		if schema.MaxLength != nil && len([]rune(s)) > *schema.MaxLength {
+			return []string{at + ": too long"}
+		}
+		if schema.Pattern != "" && !regexp.MustCompile(schema.Pattern).MatchString(s) {
+			return []string{fmt.Sprintf("%s: %q doesn't match %s", at, s, schema.Pattern)}
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok || schema.Type == "integer" && n != math.Trunc(n) {
+			return []string{fmt.Sprintf("%s: %v is not an %s", at, value, schema.Type)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{fmt.Sprintf("%s: %v is not a boolean", at, value)}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v is not an array", at, value)}
+		}
+		var problems []string
+		for i, item := range items {
+			problems = append(problems, d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+		return problems
+	case "object":
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: %v is not an object", at, value)}
+		}
+		var problems []string
+		for _, name := range schema.Required {
+			if _, ok := fields[name]; !ok {
+				problems = append(problems, at+"."+name+": missing")
+			}
+		}
+		var additional *openAPISchema
+		if len(schema.AdditionalProperties) > 0 && string(schema.AdditionalProperties) != "true" && string(schema.AdditionalProperties) != "false" {
+			_ = json.Unmarshal(schema.AdditionalProperties, &additional)
+		}
+		for name, field := range fields {
+			switch property := schema.Properties[name]; {
+			case property != nil:
+				problems = append(problems, d.validate(property, field, at+"."+name)...)
+			case additional != nil:
+				problems = append(problems, d.validate(additional, field, at+"."+name)...)
+			case string(schema.AdditionalProperties) == "false":
+				problems = append(problems, at+"."+name+": not in the schema")
+			}
+		}
+		return problems
+	}
+	return nil
+}
+
+func TestOpenAPI(t *testing.T) {
+	stored := &StoredRepository{event: models.Event{
+		UserID:     "1",
+		EventID:    "e1",
+		Date:       "2025-09-29",
+		StartTime:  "10:00",
+		Event:      "doctor",
+		Visibility: models.VisibilityBusy,
+		Tags:       []string{"health"},
+		Reminders:  []*models.Reminder{{ReminderID: "r1", EventID: "e1", OffsetMinutes: 15, Channel: models.ChannelLog}},
+		Version:    2,
+	}}
+	storedRouter := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, stored))
+	fakeRouter := newTestRouter(t, &FakeCalendarService{})
+	reportRouter := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, &ReportRepository{}))
+	memoryRouter := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, newMemoryRepository(storedEvents()...)))
+	history := newHistoryRepository()
+	_ = history.UpdateEvent(&models.Event{UserID: "1", EventID: "e1", Date: "2025-09-30", Event: "standup", Version: 1})
+	historyRouter := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, history))
+	quotaRouter := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{MaxEvents: 2}, newHistoryRepository()))
+
+	rec := serve(fakeRouter, http.MethodGet, "/openapi.json", "", nil)
+	var doc openAPIDocument
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("openapi.json = %d, %v", rec.Code, err)
+	}
+	if rec = serve(fakeRouter, http.MethodGet, "/docs", "", nil); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/openapi.json") {
+		t.Errorf("docs = %d, want a page loading the document", rec.Code)
+	}
+
+	for _, route := range fakeRouter.Routes() {
+		path := regexp.MustCompile(`:(\w+)`).ReplaceAllString(route.Path, "{$1}")
+		if _, ok := doc.Paths[path][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("%s %s is not documented", route.Method, path)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		route  string
+		router http.Handler
+		method string
+		target string
+		body   string
+		header map[string]string
+		status int
+	}{
+		{name: "create", router: fakeRouter, method: http.MethodPost, target: "/api/v1/create_event", body: `{"user_id": "1", "event": "standup", "date": "2025-09-29"}`, status: http.StatusOK},
+		{name: "create invalid", router: storedRouter, method: http.MethodPost, target: "/api/v1/create_event", body: `{"user_id": "1", "event": "standup", "date": "29.09.2025"}`, status: http.StatusBadRequest},
+		{name: "get", router: storedRouter, method: http.MethodGet, target: "/api/v1/event?event_id=e1&user_id=1", status: http.StatusOK},
+		{name: "get missing", router: storedRouter, method: http.MethodGet, target: "/api/v1/event?event_id=e2&user_id=1", status: http.StatusNotFound},
+		{name: "update stale", router: storedRouter, method: http.MethodPost, target: "/api/v1/update_event", body: `{"event_id": "e1", "user_id": "1", "event": "doctor", "date": "2025-09-29", "version": 1}`, status: http.StatusPreconditionFailed},
+		{name: "patch v1", route: "/api/v1/events/{event_id}", router: storedRouter, method: http.MethodPatch, target: "/api/v1/events/e1", body: `{"location": "room 5"}`, header: map[string]string{"If-Match": `"2"`}, status: http.StatusOK},
+		{name: "patch wrong type", route: "/api/v1/events/{event_id}", router: storedRouter, method: http.MethodPatch, target: "/api/v1/events/e1", body: `{}`, header: map[string]string{"Content-Type": "text/plain"}, status: http.StatusUnsupportedMediaType},
+		{name: "delete without version", router: fakeRouter, method: http.MethodPost, target: "/api/v1/delete_event", body: `{"id": "e1"}`, status: http.StatusPreconditionRequired},
+		{name: "delete", router: fakeRouter, method: http.MethodPost, target: "/api/v1/delete_event", body: `{"id": "e1", "version": 1}`, status: http.StatusOK},
+		{name: "query", router: fakeRouter, method: http.MethodGet, target: "/api/v1/events?calendar_ids=1&from=2025-09-01&to=2025-09-30", status: http.StatusOK},
+		{name: "report", router: reportRouter, method: http.MethodGet, target: "/api/v1/report?user_id=1&calendar_ids=1&from=2025-09-01&to=2025-09-30", status: http.StatusOK},
+		{name: "v2 create", router: fakeRouter, method: http.MethodPost, target: "/api/v2/events", body: `{"user_id": "1", "event": "standup", "date": "2025-09-29"}`, status: http.StatusCreated},
+		{name: "v2 create over quota", router: quotaRouter, method: http.MethodPost, target: "/api/v2/events", body: `{"user_id": "1", "event": "standup", "date": "2025-09-29"}`, status: http.StatusForbidden},
+		{name: "v2 batch", router: memoryRouter, method: http.MethodPost, target: "/api/v2/events/batch", body: `{"mode": "best_effort", "operations": [{"op": "create", "event": {"user_id": "1", "event": "retro", "date": "2025-09-29"}}, {"op": "update", "event": {"event_id": "e1", "user_id": "1", "event": "standup", "date": "2025-09-30", "version": 2}}, {"op": "delete", "event_id": "e2", "version": 1}]}`, status: http.StatusOK},
+		{name: "v2 get busy", route: "/api/v2/events/{event_id}", router: storedRouter, method: http.MethodGet, target: "/api/v2/events/e1?user_id=2", status: http.StatusOK},
+		{name: "v2 get not modified", route: "/api/v2/events/{event_id}", router: storedRouter, method: http.MethodGet, target: "/api/v2/events/e1?user_id=2", header: map[string]string{"If-None-Match": `"3"`}, status: http.StatusNotModified},
+		{name: "v2 put", route: "/api/v2/events/{event_id}", router: storedRouter, method: http.MethodPut, target: "/api/v2/events/e1", body: `{"user_id": "1", "event": "dentist", "date": "2025-09-30"}`, header: map[string]string{"If-Match": `"3"`}, status: http.StatusOK},
+		{name: "v2 delete", route: "/api/v2/events/{event_id}", router: fakeRouter, method: http.MethodDelete, target: "/api/v2/events/e1?version=1", status: http.StatusNoContent},
+		{name: "v2 restore missing", route: "/api/v2/events/{event_id}/restore", router: memoryRouter, method: http.MethodPost, target: "/api/v2/events/e1/restore", status: http.StatusNotFound},
+		{name: "v2 history", route: "/api/v2/events/{event_id}/history", router: historyRouter, method: http.MethodGet, target: "/api/v2/events/e1/history?user_id=1", status: http.StatusOK},
+		{name: "v2 revert", route: "/api/v2/events/{event_id}/revert", router: historyRouter, method: http.MethodPost, target: "/api/v2/events/e1/revert?user_id=1", body: `{"revision": 1, "version": 2}`, status: http.StatusOK},
+		{name: "v2 audit", router: historyRouter, method: http.MethodGet, target: "/api/v2/admin/audit?actor=1&limit=1", header: map[string]string{"X-API-Key": testAdminKey}, status: http.StatusOK},
+		{name: "v2 audit without key", route: "/api/v2/admin/audit", router: historyRouter, method: http.MethodGet, target: "/api/v2/admin/audit", status: http.StatusUnauthorized},
+		{name: "v2 trash", route: "/api/v2/users/{user_id}/trash", router: memoryRouter, method: http.MethodGet, target: "/api/v2/users/1/trash", status: http.StatusOK},
+		{name: "v2 user events", route: "/api/v2/users/{user_id}/events", router: fakeRouter, method: http.MethodGet, target: "/api/v2/users/1/events?from=2025-09-01&to=2025-09-30", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.router, tt.method, tt.target, tt.body, tt.header)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body, tt.status)
+			}
+			route := strings.SplitN(tt.target, "?", 2)[0]
+			if tt.route != "" {
+				route = tt.route
+			}
+			operation, ok := doc.Paths[route][strings.ToLower(tt.method)]
+			if !ok {
+				t.Fatalf("%s %s is not documented", tt.method, route)
+			}
+			response, ok := operation.Responses[fmt.Sprint(rec.Code)]
+			if !ok {
+				t.Fatalf("status %d of %s %s is not documented", rec.Code, tt.method, route)
+			}
+			if rec.Body.Len() == 0 {
+				return
+			}
+			content, ok := response.Content["application/json"]
+			if !ok {
+				t.Fatalf("JSON response of %s %s is not documented", tt.method, route)
+			}
+			var body any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			for _, problem := range doc.validate(content.Schema, body, "response") {
+				t.Error(problem)
+			}
+		})
+	}
+}
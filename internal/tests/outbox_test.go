@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"Calendar/internal/eventbus"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/scheduler"
+	"context"
+	"github.com/google/uuid"
+	"sync"
+	"testing"
+	"time"
+)
+
+// relayOnce runs one relay pass over the outbox and returns the changes of
+// userID it published.
+func relayOnce(t *testing.T, ctx context.Context, repo repository.OutboxRepositoryInterface, userID string) []*models.DomainEvent {
+	t.Helper()
+	bus := eventbus.NewInMemoryBus()
+	var mu sync.Mutex
+	var published []*models.DomainEvent
+	bus.Subscribe("test", func(ctx context.Context, event *models.DomainEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if event.UserID == userID {
+			published = append(published, event)
+		}
+		return nil
+	})
+	// With its context cancelled the relay stops after a single pass.
+	stopped, cancel := context.WithCancel(ctx)
+	cancel()
+	scheduler.NewOutboxRelay(stopped, scheduler.RelayConfig{PollInterval: time.Hour, BatchSize: 10000}, repo, bus).Run()
+	return published
+}
+
+func TestOutboxRelay_Burst(t *testing.T) {
+	ctx, db := newTestDatabase(t)
+	repo := repository.NewCalendarRepository(ctx, db, 0)
+	event := &models.Event{EventID: uuid.New().String(), UserID: uuid.New().String(), Event: "standup", Date: "2025-09-29"}
+	if err := repo.CreateEvent(event); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	for range 4 {
+		event.Event += "!"
+		if err := repo.UpdateEvent(event); err != nil {
+			t.Fatalf("update error = %v", err)
+		}
+	}
+
+	published := relayOnce(t, ctx, repository.NewOutboxRepository(ctx, db), event.UserID)
+	if len(published) != 5 {
+		t.Fatalf("published %d changes in one pass, want 5", len(published))
+	}
+	for i, change := range published[1:] {
+		if change.Seq <= published[i].Seq {
+			t.Errorf("change %d has seq %d after %d, want commit order", i+1, change.Seq, published[i].Seq)
+		}
+	}
+}
+
+func TestOutboxRelay_MovedEvent(t *testing.T) {
+	ctx, db := newTestDatabase(t)
+	repo := repository.NewCalendarRepository(ctx, db, 0)
+	event := &models.Event{EventID: uuid.New().String(), UserID: uuid.New().String(), Event: "standup", Date: "2025-09-29"}
+	if err := repo.CreateEvent(event); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	outbox := repository.NewOutboxRepository(ctx, db)
+	previous := event.UserID
+	relayOnce(t, ctx, outbox, previous)
+
+	event.UserID = uuid.New().String()
+	if err := repo.UpdateEvent(event); err != nil {
+		t.Fatalf("update error = %v", err)
+	}
+	published := relayOnce(t, ctx, outbox, previous)
+	if len(published) != 1 || published[0].Type != models.EventDeleted || published[0].EventID != event.EventID {
+		t.Fatalf("previous owner got %+v, want the event deleted", published)
+	}
+}
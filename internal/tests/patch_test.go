@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/service"
+	"context"
+	errors1 "errors"
+	"fmt"
+	"testing"
+)
+
+// StoredRepository keeps a single event in memory.
+type StoredRepository struct {
+	repository.CalendarRepositoryInterface
+	event models.Event
+}
+
+func (m *StoredRepository) GetEvent(eventID string) (*models.Event, error) {
+	if eventID != m.event.EventID {
+		return nil, fmt.Errorf("%w with id: %s", repository.ErrEventNotFound, eventID)
+	}
+	event := m.event
+	return &event, nil
+}
+
+func (m *StoredRepository) UpdateEvent(event *models.Event) error {
+	if event.Version != m.event.Version {
+		return &repository.VersionConflictError{EventID: event.EventID, Version: m.event.Version}
+	}
+	event.Version++
+	m.event = *event
+	return nil
+}
+
+func TestCalendarService_PatchEvent(t *testing.T) {
+	repo := &StoredRepository{event: models.Event{
+		UserID:    "1",
+		EventID:   "e1",
+		Date:      "2025-09-29",
+		StartTime: "10:00",
+		Event:     "standup",
+		Location:  "room 5",
+		Status:    models.StatusTentative,
+		Tags:      []string{"team"},
+		Reminders: []*models.Reminder{{OffsetMinutes: 15, Channel: models.ChannelLog}},
+		Version:   2,
+	}}
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+
+	event, err := srv.PatchEvent("e1", []byte(`{"description": "daily sync", "location": null, "status": null, "version": 2}`), 0)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if event.Event != "standup" || event.StartTime != "10:00" || len(event.Tags) != 1 || len(event.Reminders) != 1 {
+		t.Errorf("event = %+v, want untouched fields kept", event)
+	}
+	if event.Description != "daily sync" || event.Location != "" || event.Status != models.StatusConfirmed {
+		t.Errorf("event = %+v, want description set, location cleared and status reset", event)
+	}
+	if event.Version != 3 {
+		t.Errorf("version = %d, want 3", event.Version)
+	}
+
+	tests := []struct {
+		name    string
+		patch   string
+		version int64
+		check   func(err error) bool
+	}{
+		{name: "stale version", patch: `{"event": "retro"}`, version: 2, check: func(err error) bool {
+			var target *errors.PreconditionFailedError
+			return errors1.As(err, &target) && target.Version == 3
+		}},
+		{name: "no version", patch: `{"event": "retro"}`, check: func(err error) bool {
+			var target *errors.PreconditionRequiredError
+			return errors1.As(err, &target)
+		}},
+		{name: "invalid merged event", patch: `{"date": "29.09.2025"}`, version: 3, check: isValidationError("date")},
+		{name: "title removed", patch: `{"event": null}`, version: 3, check: isValidationError("event")},
+		{name: "wrong type", patch: `{"start_time": 10}`, version: 3, check: isValidationError("start_time")},
+		{name: "not an object", patch: `["event"]`, version: 3, check: isValidationError("request_body")},
+		{name: "id changed", patch: `{"event_id": "e2"}`, version: 3, check: isValidationError("event_id")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.PatchEvent("e1", []byte(tt.patch), tt.version)
+			if !tt.check(err) {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func isValidationError(field string) func(err error) bool {
+	return func(err error) bool {
+		var target *errors.ValidationError
+		return errors1.As(err, &target) && target.Field == field
+	}
+}
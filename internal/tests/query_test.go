@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/service"
+	"context"
+	errors1 "errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// PagedRepository serves total events of one day, honouring limit and cursor.
+type PagedRepository struct {
+	repository.CalendarRepositoryInterface
+	total     int
+	weekStart string
+	queries   []*models.EventQuery
+}
+
+func (m *PagedRepository) GetUserSettings(userID string) (*models.UserSettings, error) {
+	weekStart := m.weekStart
+	if weekStart == "" {
+		weekStart = models.WeekStartMonday
+	}
+	return &models.UserSettings{UserID: userID, WeekStart: weekStart}, nil
+}
+
+func (m *PagedRepository) QueryEvents(query *models.EventQuery) ([]*models.Event, error) {
+	m.queries = append(m.queries, query)
+	start := 0
+	if query.After != nil {
+		fmt.Sscanf(query.After.EventID, "%d", &start)
+		start++
+	}
+	var events []*models.Event
+	for i := start; i < m.total && len(events) < query.Limit; i++ {
+		events = append(events, &models.Event{EventID: fmt.Sprintf("%03d", i), UserID: "1", Date: "2025-09-29", Event: "event"})
+	}
+	return events, nil
+}
+
+func TestCalendarService_QueryEvents(t *testing.T) {
+	repo := &PagedRepository{total: 3}
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+
+	page, err := srv.QueryEvents(&models.EventQueryParams{
+		CalendarIDs: []string{"1"},
+		From:        "2025-09-01",
+		To:          "2025-09-30",
+		Limit:       "2",
+	})
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if len(page.Events) != 2 || page.NextCursor == "" {
+		t.Fatalf("first page = %d events, cursor %q; want 2 events and a cursor", len(page.Events), page.NextCursor)
+	}
+
+	page, err = srv.QueryEvents(&models.EventQueryParams{
+		CalendarIDs: []string{"1"},
+		From:        "2025-09-01",
+		To:          "2025-09-30",
+		Limit:       "2",
+		Cursor:      page.NextCursor,
+	})
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if len(page.Events) != 1 || page.Events[0].EventID != "002" || page.NextCursor != "" {
+		t.Errorf("second page = %+v, want only event 002 and no cursor", page)
+	}
+
+	tests := []struct {
+		name   string
+		params *models.EventQueryParams
+		field  string
+	}{
+		{name: "no calendars", params: &models.EventQueryParams{From: "2025-09-01", To: "2025-09-30"}, field: "calendar_ids"},
+		{name: "bad from", params: &models.EventQueryParams{CalendarIDs: []string{"1"}, From: "09/01/2025", To: "2025-09-30"}, field: "from"},
+		{name: "to before from", params: &models.EventQueryParams{CalendarIDs: []string{"1"}, From: "2025-09-30", To: "2025-09-01"}, field: "to"},
+		{name: "bad sort", params: &models.EventQueryParams{CalendarIDs: []string{"1"}, From: "2025-09-01", To: "2025-09-30", Sort: "title"}, field: "sort"},
+		{name: "bad cursor", params: &models.EventQueryParams{CalendarIDs: []string{"1"}, From: "2025-09-01", To: "2025-09-30", Cursor: "???"}, field: "cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.QueryEvents(tt.params)
+			var target *errors.ValidationError
+			if !errors1.As(err, &target) || target.Field != tt.field {
+				t.Errorf("error = %v, want validation error on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestCalendarService_GetEventsForWeekReadsAllPages(t *testing.T) {
+	repo := &PagedRepository{total: 1200}
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+
+	events, err := srv.GetEventsForWeek("1", "2025-09-29", models.WindowRolling, nil)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if len(events) != 1200 {
+		t.Errorf("got %d events, want 1200", len(events))
+	}
+	if len(repo.queries) != 3 {
+		t.Errorf("got %d queries, want 3", len(repo.queries))
+	}
+}
+
+func TestCalendarService_Windows(t *testing.T) {
+	tests := []struct {
+		name      string
+		weekStart string
+		get       func(srv *service.CalendarService) error
+		from      string
+		to        string
+	}{
+		{
+			name: "iso week",
+			get: func(srv *service.CalendarService) error {
+				_, err := srv.GetEventsForWeek("1", "2025-10-01", "", nil)
+				return err
+			},
+			from: "2025-09-29",
+			to:   "2025-10-05",
+		},
+		{
+			name:      "sunday week",
+			weekStart: models.WeekStartSunday,
+			get: func(srv *service.CalendarService) error {
+				_, err := srv.GetEventsForWeek("1", "2025-10-01", models.WindowCalendar, nil)
+				return err
+			},
+			from: "2025-09-28",
+			to:   "2025-10-04",
+		},
+		{
+			name:      "sunday week on a sunday",
+			weekStart: models.WeekStartSunday,
+			get: func(srv *service.CalendarService) error {
+				_, err := srv.GetEventsForWeek("1", "2025-09-28", "", nil)
+				return err
+			},
+			from: "2025-09-28",
+			to:   "2025-10-04",
+		},
+		{
+			name: "rolling week",
+			get: func(srv *service.CalendarService) error {
+				_, err := srv.GetEventsForWeek("1", "2025-10-01", models.WindowRolling, nil)
+				return err
+			},
+			from: "2025-10-01",
+			to:   "2025-10-07",
+		},
+		{
+			name: "calendar month",
+			get: func(srv *service.CalendarService) error {
+				_, err := srv.GetEventsForMonth("1", "2024-02-15", "", nil)
+				return err
+			},
+			from: "2024-02-01",
+			to:   "2024-02-29",
+		},
+		{
+			name: "rolling month",
+			get: func(srv *service.CalendarService) error {
+				_, err := srv.GetEventsForMonth("1", "2025-09-15", models.WindowRolling, nil)
+				return err
+			},
+			from: "2025-09-15",
+			to:   "2025-10-14",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &PagedRepository{weekStart: tt.weekStart}
+			srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+			if err := tt.get(srv); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			query := repo.queries[0]
+			from, to := query.From.Format(time.DateOnly), query.To.Format(time.DateOnly)
+			if from != tt.from || to != tt.to {
+				t.Errorf("window = %s..%s, want %s..%s", from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
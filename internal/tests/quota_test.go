@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/service"
+	"context"
+	"encoding/json"
+	errors1 "errors"
+	"github.com/google/uuid"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func (h *HistoryRepository) CountEvents(userID string) (int, error) {
+	count := 0
+	for _, event := range h.events {
+		if event.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (h *HistoryRepository) GetCalendarsCreatedBy(actorID string) ([]string, error) {
+	var calendarIDs []string
+	for eventID, history := range h.history {
+		event, ok := h.events[eventID]
+		if ok && history[0].Actor == actorID && !slices.Contains(calendarIDs, event.UserID) {
+			calendarIDs = append(calendarIDs, event.UserID)
+		}
+	}
+	return calendarIDs, nil
+}
+
+func TestCalendarService_Quota(t *testing.T) {
+	isQuotaExceeded := func(resource string) func(err error) bool {
+		return func(err error) bool {
+			var target *errors.QuotaExceededError
+			return errors1.As(err, &target) && target.Resource == resource
+		}
+	}
+	repo := newHistoryRepository()
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{MaxEvents: 3, MaxCalendars: 2}, repo)
+	acting := service.WithActor(srv, "1")
+
+	if _, err := acting.CreateEvent(&models.Event{UserID: "1", Date: "2025-09-29", Event: "retro"}); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	if _, err := acting.CreateEvent(&models.Event{UserID: "1", Date: "2025-09-29", Event: "demo"}); !isQuotaExceeded("events")(err) {
+		t.Errorf("create in a full calendar = %v, want events quota exceeded", err)
+	}
+
+	shared := &models.Event{UserID: "2", Date: "2025-09-29", Event: "review"}
+	if _, err := acting.CreateEvent(shared); err != nil {
+		t.Fatalf("create in a second calendar = %v", err)
+	}
+	if _, err := acting.CreateEvent(&models.Event{UserID: "3", Date: "2025-09-29", Event: "review"}); !isQuotaExceeded("calendars")(err) {
+		t.Errorf("create in a third calendar = %v, want calendars quota exceeded", err)
+	}
+	if _, err := srv.CreateEvent(&models.Event{UserID: "3", Date: "2025-09-29", Event: "review"}); err != nil {
+		t.Errorf("create by the owner without acting user = %v", err)
+	}
+
+	moved := *shared
+	moved.UserID = "1"
+	if err := srv.UpdateEvent(&moved); !isQuotaExceeded("events")(err) {
+		t.Errorf("move to a full calendar = %v, want events quota exceeded", err)
+	}
+	shared.Event = "design review"
+	if err := srv.UpdateEvent(shared); err != nil {
+		t.Errorf("update in place = %v", err)
+	}
+
+	create := func() *models.BatchOperation {
+		return &models.BatchOperation{Op: models.BatchCreate, Event: &models.Event{UserID: "2", Date: "2025-09-30", Event: "sync"}}
+	}
+	results, err := acting.ApplyBatch(&models.Batch{Operations: []*models.BatchOperation{create(), create(), create()}})
+	if err != nil {
+		t.Fatalf("batch error = %v", err)
+	}
+	if !isQuotaExceeded("events")(results[2].Err) || results[0].Err == nil || results[1].Err == nil {
+		t.Errorf("atomic batch over quota = %v, %v, %v", results[0].Err, results[1].Err, results[2].Err)
+	}
+	results, _ = acting.ApplyBatch(&models.Batch{Mode: models.BatchBestEffort, Operations: []*models.BatchOperation{create(), create(), create()}})
+	if results[0].Err != nil || results[1].Err != nil || !isQuotaExceeded("events")(results[2].Err) {
+		t.Errorf("best effort batch over quota = %v, %v, %v", results[0].Err, results[1].Err, results[2].Err)
+	}
+}
+
+func TestAPI_Quota(t *testing.T) {
+	router := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{MaxEvents: 2}, newHistoryRepository()))
+
+	rec := serve(router, http.MethodPost, "/api/v2/events", `{"user_id": "1", "date": "2025-09-29", "event": "retro"}`, nil)
+	var resp struct {
+		Error   string
+		Details map[string]string
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusForbidden {
+		t.Fatalf("create = %d %s, want 403", rec.Code, rec.Body)
+	}
+	if resp.Error != "quota_exceeded" || resp.Details["limit"] != "2" {
+		t.Errorf("response = %s, want quota_exceeded with limit 2", rec.Body)
+	}
+}
+
+func TestAPI_QuotaDefaults(t *testing.T) {
+	router := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{MaxEvents: 10000, MaxCalendars: 20}, newHistoryRepository()))
+
+	rec := serve(router, http.MethodPost, "/api/v1/create_event", `{"user_id": "1", "event": "standup", "date": "2025-09-29"}`, nil)
+	if rec.Code != http.StatusOK {
+		t.Errorf("v1 create = %d %s, want 200", rec.Code, rec.Body)
+	}
+	rec = serve(router, http.MethodPost, "/api/v2/events", `{"user_id": "1", "event": "retro", "date": "2025-09-29"}`, nil)
+	if rec.Code != http.StatusCreated {
+		t.Errorf("v2 create = %d %s, want 201", rec.Code, rec.Body)
+	}
+}
+
+func TestCalendarRepository_EventLimit(t *testing.T) {
+	ctx, db := newTestDatabase(t)
+	repo := repository.NewCalendarRepository(ctx, db, 3)
+	userID := uuid.New().String()
+
+	errs := make([]error, 10)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = repo.CreateEvent(&models.Event{EventID: uuid.New().String(), UserID: userID, Event: "standup", Date: "2025-09-29"})
+		}()
+	}
+	wg.Wait()
+	created := 0
+	for _, err := range errs {
+		var quota *repository.QuotaExceededError
+		switch {
+		case err == nil:
+			created++
+		case !errors1.As(err, &quota):
+			t.Errorf("create error = %v, want quota exceeded", err)
+		}
+	}
+	if created != 3 {
+		t.Errorf("created %d events concurrently, want 3", created)
+	}
+}
+
+func TestCalendarRepository_RestoreOverLimit(t *testing.T) {
+	ctx, db := newTestDatabase(t)
+	repo := repository.NewCalendarRepository(ctx, db, 1)
+	userID := uuid.New().String()
+
+	trashed := &models.Event{EventID: uuid.New().String(), UserID: userID, Event: "standup", Date: "2025-09-29"}
+	if err := repo.CreateEvent(trashed); err != nil {
+		t.Fatalf("create error = %v", err)
+	}
+	if err := repo.DeleteEvent(trashed.EventID, trashed.Version); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+	if err := repo.CreateEvent(&models.Event{EventID: uuid.New().String(), UserID: userID, Event: "retro", Date: "2025-09-29"}); err != nil {
+		t.Fatalf("create after delete error = %v", err)
+	}
+	var quota *repository.QuotaExceededError
+	if _, err := repo.RestoreEvent(trashed.EventID); !errors1.As(err, &quota) {
+		t.Errorf("restore into a full calendar = %v, want quota exceeded", err)
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"Calendar/internal/config"
+	"Calendar/internal/ratelimit"
+	"Calendar/internal/transport"
+	"Calendar/pkg/logger"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := ratelimit.NewLimiter(time.Minute)
+	start := time.Date(2025, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		key     string
+		at      time.Duration
+		allowed bool
+		want    ratelimit.Result
+	}{
+		{name: "first", key: "a", want: ratelimit.Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 30 * time.Second}},
+		{name: "second", key: "a", want: ratelimit.Result{Allowed: true, Limit: 2, Remaining: 0, Reset: time.Minute}},
+		{name: "empty bucket", key: "a", want: ratelimit.Result{Limit: 2, Reset: time.Minute, RetryAfter: 30 * time.Second}},
+		{name: "other client", key: "b", want: ratelimit.Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 30 * time.Second}},
+		{name: "refilled token", key: "a", at: 30 * time.Second, want: ratelimit.Result{Allowed: true, Limit: 2, Remaining: 0, Reset: time.Minute}},
+		{name: "after a window", key: "a", at: 2 * time.Minute, want: ratelimit.Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 30 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limiter.Allow(tt.key, 2, start.Add(tt.at)); got != tt.want {
+				t.Errorf("Allow() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPI_RateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, err := logger.New(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{RateLimit: ratelimit.Config{Enabled: true, Window: time.Minute, APIKeys: []string{"k1"}, APIKeyLimit: 3, UserLimit: 2, IPLimit: 4}}
+	router := transport.NewCalendarServer(ctx, cfg, &FakeCalendarService{}, nil, nil, nil).Router()
+
+	tests := []struct {
+		name      string
+		target    string
+		header    map[string]string
+		status    int
+		remaining string
+	}{
+		{name: "user", target: "/openapi.json?user_id=1", status: http.StatusOK, remaining: "1"},
+		{name: "user again", target: "/openapi.json?user_id=1", status: http.StatusOK, remaining: "0"},
+		{name: "user over limit", target: "/openapi.json?user_id=1", status: http.StatusTooManyRequests, remaining: "0"},
+		{name: "other user takes the last ip token", target: "/openapi.json?user_id=2", status: http.StatusOK, remaining: "0"},
+		{name: "ip over limit", target: "/openapi.json", status: http.StatusTooManyRequests, remaining: "0"},
+		{name: "new user over ip limit", target: "/openapi.json?user_id=3", status: http.StatusTooManyRequests, remaining: "0"},
+		{name: "spoofed forwarded ip", target: "/openapi.json", header: map[string]string{"X-Forwarded-For": "203.0.113.9"}, status: http.StatusTooManyRequests, remaining: "0"},
+		{name: "unknown api key", target: "/openapi.json", header: map[string]string{"X-API-Key": "made-up"}, status: http.StatusTooManyRequests, remaining: "0"},
+		{name: "api key", target: "/openapi.json?user_id=1", header: map[string]string{"X-API-Key": "k1"}, status: http.StatusOK, remaining: "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodGet, tt.target, "", tt.header)
+			if rec.Code != tt.status || rec.Header().Get("RateLimit-Remaining") != tt.remaining {
+				t.Fatalf("status = %d, remaining %q; want %d, %q", rec.Code, rec.Header().Get("RateLimit-Remaining"), tt.status, tt.remaining)
+			}
+			if rec.Header().Get("RateLimit-Limit") == "" || rec.Header().Get("RateLimit-Reset") == "" || rec.Header().Get("RateLimit-Policy") == "" {
+				t.Errorf("headers = %v, want RateLimit-*", rec.Header())
+			}
+			if tt.status != http.StatusTooManyRequests {
+				return
+			}
+			var resp transport.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error != "rate_limited" {
+				t.Errorf("body = %s, want rate_limited", rec.Body)
+			}
+			if rec.Header().Get("Retry-After") == "" {
+				t.Errorf("headers = %v, want Retry-After", rec.Header())
+			}
+		})
+	}
+}
+
+func TestAPI_RateLimitTrustedProxy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, err := logger.New(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		RateLimit:      ratelimit.Config{Enabled: true, Window: time.Minute, IPLimit: 1},
+		TrustedProxies: []string{"192.0.2.0/24"},
+	}
+	router := transport.NewCalendarServer(ctx, cfg, &FakeCalendarService{}, nil, nil, nil).Router()
+
+	forwarded := func(ip string) map[string]string { return map[string]string{"X-Forwarded-For": ip} }
+	if rec := serve(router, http.MethodGet, "/openapi.json", "", forwarded("203.0.113.9")); rec.Code != http.StatusOK {
+		t.Fatalf("first client = %d, want 200", rec.Code)
+	}
+	if rec := serve(router, http.MethodGet, "/openapi.json", "", forwarded("203.0.113.9")); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("first client again = %d, want 429", rec.Code)
+	}
+	if rec := serve(router, http.MethodGet, "/openapi.json", "", forwarded("203.0.113.10")); rec.Code != http.StatusOK {
+		t.Errorf("second client behind the proxy = %d, want 200", rec.Code)
+	}
+}
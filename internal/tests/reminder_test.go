@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"Calendar/internal/models"
+	"Calendar/internal/scheduler"
+	"Calendar/pkg/logger"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ReminderJobs hands out its jobs on the first claim and records how they
+// were settled.
+type ReminderJobs struct {
+	mu        sync.Mutex
+	jobs      []*models.ReminderJob
+	completed []string
+	retried   []string
+}
+
+func (r *ReminderJobs) ClaimDueJobs(limit int, lease time.Duration) ([]*models.ReminderJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	jobs := r.jobs
+	r.jobs = nil
+	return jobs, nil
+}
+
+func (r *ReminderJobs) CompleteJob(jobID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.completed = append(r.completed, jobID)
+	return nil
+}
+
+func (r *ReminderJobs) RetryJob(jobID string, runAt time.Time, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retried = append(r.retried, jobID)
+	return nil
+}
+
+func (r *ReminderJobs) FailJob(jobID string, reason string) error {
+	return r.RetryJob(jobID, time.Time{}, reason)
+}
+
+// blockingNotifier records the delivery IDs it gets and blocks until the
+// delivery context is done.
+type blockingNotifier struct {
+	started chan string
+}
+
+func (n *blockingNotifier) Notify(ctx context.Context, notification *models.Notification) error {
+	n.started <- notification.DeliveryID
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestReminderWorker_Stop(t *testing.T) {
+	ctx, err := logger.New(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	repo := &ReminderJobs{jobs: []*models.ReminderJob{{JobID: "j1", Attempts: 1}, {JobID: "j2", Attempts: 1}}}
+	n := &blockingNotifier{started: make(chan string, 2)}
+	cfg := scheduler.Config{PollInterval: time.Hour, MaxAttempts: 5, StopTimeout: 50 * time.Millisecond}
+	worker := scheduler.NewReminderWorker(ctx, cfg, repo, n)
+
+	done := make(chan struct{})
+	go func() {
+		worker.Run()
+		close(done)
+	}()
+	if id := <-n.started; id != "j1" {
+		t.Fatalf("delivery ID = %q, want j1", id)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop within the stop timeout")
+	}
+	if len(n.started) != 0 || len(repo.retried) != 1 || len(repo.completed) != 0 {
+		t.Errorf("started %d more, retried %v, completed %v; want only j1 retried", len(n.started), repo.retried, repo.completed)
+	}
+}
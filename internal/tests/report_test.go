@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/service"
+	"context"
+	errors1 "errors"
+	"testing"
+	"time"
+)
+
+type ReportRepository struct {
+	repository.CalendarRepositoryInterface
+	query *models.ReportQuery
+}
+
+func (m *ReportRepository) GetUserSettings(userID string) (*models.UserSettings, error) {
+	return &models.UserSettings{UserID: userID, WeekStart: models.WeekStartSunday}, nil
+}
+
+func (m *ReportRepository) Report(query *models.ReportQuery) ([]*models.ReportRow, error) {
+	m.query = query
+	return []*models.ReportRow{{Bucket: "2025-09-28", Key: "1", Hours: 1.0 / 3, Events: 1}}, nil
+}
+
+func TestCalendarService_GetReport(t *testing.T) {
+	repo := &ReportRepository{}
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+
+	report, err := srv.GetReport(&models.ReportParams{
+		ViewerID:    "1",
+		CalendarIDs: []string{"1", "2"},
+		From:        "2025-09-01",
+		To:          "2025-09-30",
+	})
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if repo.query.Bucket != models.BucketWeek || repo.query.GroupBy != models.GroupByCalendar {
+		t.Errorf("defaults = %s/%s, want week/calendar", repo.query.Bucket, repo.query.GroupBy)
+	}
+	if repo.query.WeekStart != time.Sunday {
+		t.Errorf("week start = %v, want the viewer's Sunday", repo.query.WeekStart)
+	}
+	if len(report.Rows) != 1 || report.Rows[0].Hours != 0.33 {
+		t.Errorf("rows = %+v, want hours rounded to 0.33", report.Rows)
+	}
+
+	tests := []struct {
+		name   string
+		params *models.ReportParams
+		field  string
+	}{
+		{name: "bad bucket", params: &models.ReportParams{CalendarIDs: []string{"1"}, From: "2025-09-01", To: "2025-09-30", Bucket: "year"}, field: "bucket"},
+		{name: "bad grouping", params: &models.ReportParams{CalendarIDs: []string{"1"}, From: "2025-09-01", To: "2025-09-30", GroupBy: "location"}, field: "group_by"},
+		{name: "range too long", params: &models.ReportParams{CalendarIDs: []string{"1"}, From: "2024-01-01", To: "2025-01-01"}, field: "to"},
+		{name: "no calendars", params: &models.ReportParams{From: "2025-09-01", To: "2025-09-30"}, field: "calendar_ids"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.GetReport(tt.params)
+			var validationErr *errors.ValidationError
+			if !errors1.As(err, &validationErr) || validationErr.Field != tt.field {
+				t.Errorf("error = %v, want validation error on %s", err, tt.field)
+			}
+		})
+	}
+}
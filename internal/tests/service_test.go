@@ -7,8 +7,9 @@ import (
 	"Calendar/internal/service"
 	"context"
 	errors1 "errors"
+	"slices"
+	"strconv"
 	"testing"
-	"time"
 )
 
 type MockRepository struct {
@@ -22,7 +23,7 @@ func (m *MockRepository) CreateEvent(event *models.Event) error {
 func TestCalendarService_CreateEvent(t *testing.T) {
 	ctx := context.Background()
 	repo := &MockRepository{}
-	srv := service.NewCalendarService(ctx, repo)
+	srv := service.NewCalendarService(ctx, service.QuotaConfig{}, repo)
 
 	tests := []struct {
 		name  string
@@ -75,6 +76,59 @@ func TestCalendarService_CreateEvent(t *testing.T) {
 				Message: "format must be YYYY-MM-DD",
 			},
 		},
+		{
+			name: "valid event with reminders",
+			event: &models.Event{
+				UserID:    "1",
+				Event:     "event",
+				Date:      "2025-09-29",
+				StartTime: "10:30",
+				Reminders: []*models.Reminder{
+					{OffsetMinutes: 15, Channel: models.ChannelLog},
+					{OffsetMinutes: 60, Channel: models.ChannelEmail, Target: "user@example.com"},
+				},
+			},
+			err: nil,
+		},
+		{
+			name: "invalid start time",
+			event: &models.Event{
+				UserID:    "1",
+				Event:     "event",
+				Date:      "2025-09-29",
+				StartTime: "25:00",
+			},
+			err: &errors.ValidationError{
+				Field:   "start_time",
+				Message: "format must be HH:MM",
+			},
+		},
+		{
+			name: "invalid reminder channel",
+			event: &models.Event{
+				UserID:    "1",
+				Event:     "event",
+				Date:      "2025-09-29",
+				Reminders: []*models.Reminder{{OffsetMinutes: 15, Channel: "sms"}},
+			},
+			err: &errors.ValidationError{
+				Field:   "channel",
+				Message: "must be one of log, webhook, email",
+			},
+		},
+		{
+			name: "invalid reminder webhook target",
+			event: &models.Event{
+				UserID:    "1",
+				Event:     "event",
+				Date:      "2025-09-29",
+				Reminders: []*models.Reminder{{OffsetMinutes: 15, Channel: models.ChannelWebhook, Target: "not a url"}},
+			},
+			err: &errors.ValidationError{
+				Field:   "target",
+				Message: "must be an http(s) URL for webhook reminders",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -99,14 +153,14 @@ func TestCalendarService_CreateEvent(t *testing.T) {
 	}
 }
 
-func (m *MockRepository) DeleteEvent(eventID string) error {
+func (m *MockRepository) DeleteEvent(eventID string, version int64) error {
 	return nil
 }
 
 func TestCalendarService_DeleteEvent(t *testing.T) {
 	ctx := context.Background()
 	repo := &MockRepository{}
-	srv := service.NewCalendarService(ctx, repo)
+	srv := service.NewCalendarService(ctx, service.QuotaConfig{}, repo)
 
 	tests := []struct {
 		name    string
@@ -130,7 +184,7 @@ func TestCalendarService_DeleteEvent(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := srv.DeleteEvent(tt.eventID)
+			err := srv.DeleteEvent(tt.eventID, 1)
 			if tt.err == nil {
 				if err != nil {
 					t.Errorf("error = %v, wantErr %v", err, tt.err)
@@ -158,7 +212,7 @@ func (m *MockRepository) UpdateEvent(event *models.Event) error {
 func TestCalendarService_UpdateEvent(t *testing.T) {
 	ctx := context.Background()
 	repo := &MockRepository{}
-	srv := service.NewCalendarService(ctx, repo)
+	srv := service.NewCalendarService(ctx, service.QuotaConfig{}, repo)
 
 	tests := []struct {
 		name  string
@@ -170,6 +224,7 @@ func TestCalendarService_UpdateEvent(t *testing.T) {
 			event: &models.Event{
 				UserID:  "1",
 				EventID: "1",
+				Version: 1,
 				Event:   "event",
 				Date:    "2025-09-29",
 			},
@@ -180,6 +235,7 @@ func TestCalendarService_UpdateEvent(t *testing.T) {
 			event: &models.Event{
 				UserID:  "",
 				EventID: "1",
+				Version: 1,
 				Event:   "event",
 				Date:    "2025-09-29",
 			},
@@ -193,6 +249,7 @@ func TestCalendarService_UpdateEvent(t *testing.T) {
 			event: &models.Event{
 				UserID:  "1",
 				EventID: "1",
+				Version: 1,
 				Event:   "",
 				Date:    "2025-09-29",
 			},
@@ -206,6 +263,7 @@ func TestCalendarService_UpdateEvent(t *testing.T) {
 			event: &models.Event{
 				UserID:  "1",
 				EventID: "1",
+				Version: 1,
 				Event:   "event",
 				Date:    "2020:31:05",
 			},
@@ -252,14 +310,14 @@ func TestCalendarService_UpdateEvent(t *testing.T) {
 	}
 }
 
-func (m *MockRepository) GetEventsForDay(userID string, date time.Time) ([]*models.Event, error) {
+func (m *MockRepository) QueryEvents(query *models.EventQuery) ([]*models.Event, error) {
 	return nil, nil
 }
 
 func TestCalendarService_GetEventsForDay(t *testing.T) {
 	ctx := context.Background()
 	repo := &MockRepository{}
-	srv := service.NewCalendarService(ctx, repo)
+	srv := service.NewCalendarService(ctx, service.QuotaConfig{}, repo)
 
 	tests := []struct {
 		name   string
@@ -304,7 +362,7 @@ func TestCalendarService_GetEventsForDay(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := srv.GetEventsForDay(tt.userID, tt.date)
+			_, err := srv.GetEventsForDay(tt.userID, tt.date, nil)
 			if tt.err == nil {
 				if err != nil {
 					t.Errorf("error = %v, wantErr %v", err, tt.err)
@@ -323,3 +381,161 @@ func TestCalendarService_GetEventsForDay(t *testing.T) {
 		})
 	}
 }
+
+func (m *MockRepository) SyncEvents(userID string, afterSeq int64, limit int) ([]*models.SyncChange, error) {
+	if afterSeq < 0 {
+		return []*models.SyncChange{
+			{Seq: 2, CreatedSeq: 2, Event: &models.Event{EventID: "b", UserID: userID}},
+			{Seq: 3, CreatedSeq: 1, Event: &models.Event{EventID: "a", UserID: userID}},
+		}, nil
+	}
+	return []*models.SyncChange{
+		{Seq: 4, Tombstone: &models.Tombstone{EventID: "b"}},
+		{Seq: 5, CreatedSeq: 1, Event: &models.Event{EventID: "a", UserID: userID}},
+	}, nil
+}
+
+func TestCalendarService_Sync(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockRepository{}
+	srv := service.NewCalendarService(ctx, service.QuotaConfig{}, repo)
+
+	full, err := srv.Sync("1", "")
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if len(full.Created) != 2 || len(full.Updated) != 0 || len(full.Deleted) != 0 {
+		t.Errorf("full sync = %+v, want 2 created", full)
+	}
+
+	delta, err := srv.Sync("1", full.SyncToken)
+	if err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if len(delta.Created) != 0 || len(delta.Updated) != 1 || len(delta.Deleted) != 1 {
+		t.Errorf("delta sync = %+v, want 1 updated and 1 deleted", delta)
+	}
+
+	_, err = srv.Sync("2", full.SyncToken)
+	var target *errors.ValidationError
+	if !errors1.As(err, &target) || target.Field != "sync_token" {
+		t.Errorf("error = %v, want sync_token validation error for another user's token", err)
+	}
+
+	_, err = srv.Sync("1", "not-a-token")
+	if !errors1.As(err, &target) || target.Field != "sync_token" {
+		t.Errorf("error = %v, want sync_token validation error", err)
+	}
+}
+
+func (m *MockRepository) SearchEvents(viewerID string, calendarIDs []string, text string, languages [2]string, limit int) ([]*models.SearchResult, error) {
+	return nil, nil
+}
+
+// SearchRepository records the searched calendars and finds one event in each.
+type SearchRepository struct {
+	MockRepository
+	calendarIDs []string
+}
+
+func (r *SearchRepository) SearchEvents(viewerID string, calendarIDs []string, text string, languages [2]string, limit int) ([]*models.SearchResult, error) {
+	r.calendarIDs = calendarIDs
+	var results []*models.SearchResult
+	for _, id := range calendarIDs {
+		event := &models.Event{UserID: id, EventID: "e" + id, Event: text, Reminders: []*models.Reminder{{OffsetMinutes: 10}}}
+		results = append(results, &models.SearchResult{Event: event})
+	}
+	return results, nil
+}
+
+func TestCalendarService_SearchCalendars(t *testing.T) {
+	repo := &SearchRepository{}
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+
+	if _, err := srv.SearchEvents("1", nil, "retro", "", ""); err != nil || !slices.Equal(repo.calendarIDs, []string{"1"}) {
+		t.Errorf("searched calendars = %v, %v, want the caller's own", repo.calendarIDs, err)
+	}
+	results, err := srv.SearchEvents("1", []string{"1", "2"}, "retro", "", "")
+	if err != nil || len(results) != 2 {
+		t.Fatalf("results = %v, %v", results, err)
+	}
+	if len(results[0].Event.Reminders) != 1 || results[1].Event.Reminders != nil {
+		t.Errorf("reminders = %v, %v, want only the caller's own", results[0].Event.Reminders, results[1].Event.Reminders)
+	}
+	many := make([]string, 51)
+	for i := range many {
+		many[i] = strconv.Itoa(i)
+	}
+	if _, err := srv.SearchEvents("1", many, "retro", "", ""); !isValidationError("calendar_ids")(err) {
+		t.Errorf("error = %v, want calendar_ids validation error", err)
+	}
+}
+
+func TestCalendarService_SearchEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockRepository{}
+	srv := service.NewCalendarService(ctx, service.QuotaConfig{}, repo)
+
+	tests := []struct {
+		name   string
+		userID string
+		text   string
+		lang   string
+		limit  string
+		err    error
+	}{
+		{
+			name:   "valid",
+			userID: "1",
+			text:   "встреча с командой",
+			err:    nil,
+		},
+		{
+			name:   "valid english",
+			userID: "1",
+			text:   "meetings",
+			lang:   "en",
+			limit:  "10",
+			err:    nil,
+		},
+		{
+			name:   "empty query",
+			userID: "1",
+			err: &errors.ValidationError{
+				Field:   "q",
+				Message: "can't be empty",
+			},
+		},
+		{
+			name:   "unknown language",
+			userID: "1",
+			text:   "meeting",
+			lang:   "de",
+			err: &errors.ValidationError{
+				Field:   "lang",
+				Message: "must be ru or en",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := srv.SearchEvents(tt.userID, nil, tt.text, tt.lang, tt.limit)
+			if tt.err == nil {
+				if err != nil || results == nil {
+					t.Errorf("results = %v, error = %v, wantErr %v", results, err, tt.err)
+				}
+				return
+			}
+			var target *errors.ValidationError
+			if errors1.As(err, &target) {
+				if target.Field != tt.err.(*errors.ValidationError).Field ||
+					target.Message != tt.err.(*errors.ValidationError).Message {
+					t.Errorf("error = %v, wantErr %v", err, tt.err)
+				}
+			} else {
+				t.Errorf("error = %v, wantErr %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/service"
+	"Calendar/pkg/logger"
+	"context"
+	errors1 "errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// newTestDatabase connects to the migrated database at TEST_DATABASE_URL, and
+// skips the test when it isn't set.
+func newTestDatabase(t *testing.T) (context.Context, *pgxpool.Pool) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx, err := logger.New(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	return ctx, db
+}
+
+type TagRepository struct {
+	repository.CalendarRepositoryInterface
+	names map[string]string
+}
+
+func (m *TagRepository) CreateEvent(event *models.Event) error {
+	return nil
+}
+
+func (m *TagRepository) RenameTag(tag *models.Tag) error {
+	if _, ok := m.names[tag.TagID]; !ok {
+		return fmt.Errorf("no tag found with id: %s", tag.TagID)
+	}
+	for id, name := range m.names {
+		if id != tag.TagID && name == tag.Name {
+			return repository.ErrTagExists
+		}
+	}
+	m.names[tag.TagID] = tag.Name
+	return nil
+}
+
+func TestCalendarService_EventTags(t *testing.T) {
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, &TagRepository{})
+
+	event := &models.Event{UserID: "1", Event: "standup", Date: "2025-09-29", Tags: []string{" oncall", "1:1", "OnCall"}}
+	if _, err := srv.CreateEvent(event); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if want := []string{"oncall", "1:1"}; !reflect.DeepEqual(event.Tags, want) {
+		t.Errorf("tags = %q, want %q", event.Tags, want)
+	}
+
+	tooMany := make([]string, 21)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+	for name, tags := range map[string][]string{"empty tag": {" "}, "too many tags": tooMany} {
+		t.Run(name, func(t *testing.T) {
+			_, err := srv.CreateEvent(&models.Event{UserID: "1", Event: "standup", Date: "2025-09-29", Tags: tags})
+			var validationErr *errors.ValidationError
+			if !errors1.As(err, &validationErr) || validationErr.Field != "tags" {
+				t.Errorf("error = %v, want validation error on tags", err)
+			}
+		})
+	}
+}
+
+func TestCalendarService_TagFilter(t *testing.T) {
+	repo := &PagedRepository{total: 1}
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+
+	if _, err := srv.GetEventsForDay("1", "2025-09-29", []string{"Interview", "interview", "1:1"}); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if want := []string{"Interview", "1:1"}; !reflect.DeepEqual(repo.queries[0].Tags, want) {
+		t.Errorf("tags = %q, want %q", repo.queries[0].Tags, want)
+	}
+}
+
+func TestCalendarService_RenameTag(t *testing.T) {
+	repo := &TagRepository{names: map[string]string{"a": "oncall", "b": "interview"}}
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+
+	if err := srv.RenameTag(&models.Tag{UserID: "1", TagID: "a", Name: " on-call "}); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if repo.names["a"] != "on-call" {
+		t.Errorf("name = %q, want on-call", repo.names["a"])
+	}
+
+	err := srv.RenameTag(&models.Tag{UserID: "1", TagID: "a", Name: "interview"})
+	var validationErr *errors.ValidationError
+	if !errors1.As(err, &validationErr) || validationErr.Field != "name" {
+		t.Errorf("error = %v, want validation error on name", err)
+	}
+
+	err = srv.RenameTag(&models.Tag{UserID: "1", TagID: "c", Name: "other"})
+	var businessErr *errors.BusinessError
+	if !errors1.As(err, &businessErr) {
+		t.Errorf("error = %v, want business error", err)
+	}
+
+	err = srv.MergeTags(&models.TagMerge{UserID: "1", SourceTagID: "a", TargetTagID: "a"})
+	if !errors1.As(err, &validationErr) || validationErr.Field != "target_tag_id" {
+		t.Errorf("error = %v, want validation error on target_tag_id", err)
+	}
+}
+
+func TestCalendarRepository_RetagTrashedEvent(t *testing.T) {
+	ctx, db := newTestDatabase(t)
+	repo := repository.NewCalendarRepository(ctx, db, 0)
+	userID := uuid.New().String()
+
+	trashed := &models.Event{EventID: uuid.New().String(), UserID: userID, Event: "standup", Date: "2025-09-29", Tags: []string{"oncall", "interview"}}
+	kept := &models.Event{EventID: uuid.New().String(), UserID: userID, Event: "retro", Date: "2025-09-30", Tags: []string{"oncall"}}
+	for _, event := range []*models.Event{trashed, kept} {
+		if err := repo.CreateEvent(event); err != nil {
+			t.Fatalf("create error = %v", err)
+		}
+	}
+	if err := repo.DeleteEvent(trashed.EventID, trashed.Version); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+	tags, err := repo.GetTags(userID)
+	if err != nil {
+		t.Fatalf("tags error = %v", err)
+	}
+	ids := make(map[string]string)
+	for _, tag := range tags {
+		ids[tag.Name] = tag.TagID
+	}
+
+	if err := repo.RenameTag(&models.Tag{UserID: userID, TagID: ids["oncall"], Name: "on-call"}); err != nil {
+		t.Fatalf("rename error = %v", err)
+	}
+	if err := repo.MergeTags(&models.TagMerge{UserID: userID, SourceTagID: ids["interview"], TargetTagID: ids["oncall"]}); err != nil {
+		t.Fatalf("merge error = %v", err)
+	}
+	if err := repo.DeleteTag(userID, ids["oncall"]); err != nil {
+		t.Fatalf("delete tag error = %v", err)
+	}
+	event, err := repo.GetEvent(kept.EventID)
+	if err != nil {
+		t.Fatalf("get error = %v", err)
+	}
+	if len(event.Tags) != 0 || event.Version != 3 {
+		t.Errorf("kept event = version %d %q, want version 3 without tags", event.Version, event.Tags)
+	}
+	restored, err := repo.RestoreEvent(trashed.EventID)
+	if err != nil {
+		t.Fatalf("restore error = %v", err)
+	}
+	if len(restored.Tags) != 0 {
+		t.Errorf("restored tags = %q, want none", restored.Tags)
+	}
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/service"
+	"context"
+	"encoding/json"
+	errors1 "errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func (m *MemoryRepository) GetEvent(eventID string) (*models.Event, error) {
+	event, ok := m.events[eventID]
+	if !ok {
+		return nil, fmt.Errorf("%w with id: %s", repository.ErrEventNotFound, eventID)
+	}
+	return &event, nil
+}
+
+func (m *MemoryRepository) GetTrash(userID string, limit int) ([]*models.Event, error) {
+	var events []*models.Event
+	for _, event := range m.trash {
+		if event.UserID == userID && len(events) < limit {
+			deletedAt := time.Date(2025, time.October, 1, 0, 0, 0, 0, time.UTC)
+			event.DeletedAt = &deletedAt
+			events = append(events, &event)
+		}
+	}
+	return events, nil
+}
+
+func (m *MemoryRepository) RestoreEvent(eventID string) (*models.Event, error) {
+	event, ok := m.trash[eventID]
+	if !ok {
+		return nil, fmt.Errorf("%w with id: %s", repository.ErrEventNotFound, eventID)
+	}
+	delete(m.trash, eventID)
+	event.Version++
+	m.events[eventID] = event
+	return &event, nil
+}
+
+func TestCalendarService_Trash(t *testing.T) {
+	repo := newMemoryRepository(storedEvents()...)
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+
+	if err := srv.DeleteEvent("e1", 1); err != nil {
+		t.Fatalf("delete error = %v", err)
+	}
+	var notFound *errors.NotFoundError
+	if _, err := srv.GetEvent("e1", "1"); !errors1.As(err, &notFound) {
+		t.Errorf("get trashed = %v, want not found", err)
+	}
+	trash, err := srv.GetTrash("1", "")
+	if err != nil || len(trash) != 1 || trash[0].EventID != "e1" || trash[0].DeletedAt == nil {
+		t.Fatalf("trash = %+v, %v", trash, err)
+	}
+	if trash, _ := srv.GetTrash("2", ""); trash == nil || len(trash) != 0 {
+		t.Errorf("other trash = %v, want empty", trash)
+	}
+
+	event, err := srv.RestoreEvent("e1")
+	if err != nil || event.Version != 2 {
+		t.Fatalf("restore = %+v, %v, want version 2", event, err)
+	}
+	if _, err := srv.GetEvent("e1", "1"); err != nil {
+		t.Errorf("get restored = %v", err)
+	}
+	if _, err := srv.RestoreEvent("e1"); !errors1.As(err, &notFound) {
+		t.Errorf("restore twice = %v, want not found", err)
+	}
+
+	tests := []struct {
+		name  string
+		call  func() error
+		field string
+	}{
+		{name: "trash without user", call: func() error { _, err := srv.GetTrash("", ""); return err }, field: "user_id"},
+		{name: "trash limit too big", call: func() error { _, err := srv.GetTrash("1", "501"); return err }, field: "limit"},
+		{name: "trash limit not a number", call: func() error { _, err := srv.GetTrash("1", "ten"); return err }, field: "limit"},
+		{name: "restore without id", call: func() error { _, err := srv.RestoreEvent(""); return err }, field: "event_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !isValidationError(tt.field)(err) {
+				t.Errorf("error = %v, want validation error on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestAPI_Trash(t *testing.T) {
+	repo := newMemoryRepository(storedEvents()...)
+	router := newTestRouter(t, service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo))
+
+	if rec := serve(router, http.MethodDelete, "/api/v2/events/e2?version=1", "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete = %d %s", rec.Code, rec.Body)
+	}
+	rec := serve(router, http.MethodGet, "/api/v2/users/1/trash", "", nil)
+	var resp struct{ Events []*models.Event }
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK || len(resp.Events) != 1 || resp.Events[0].EventID != "e2" {
+		t.Fatalf("trash = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(router, http.MethodPost, "/api/v2/events/e2/restore", "", nil)
+	var event models.Event
+	if err := json.Unmarshal(rec.Body.Bytes(), &event); err != nil || rec.Code != http.StatusOK || event.Version != 2 || rec.Header().Get("ETag") != `"2"` {
+		t.Fatalf("restore = %d %s %v", rec.Code, rec.Body, rec.Header())
+	}
+	if rec := serve(router, http.MethodPost, "/api/v2/events/e2/restore", "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("restore twice = %d, want 404", rec.Code)
+	}
+}
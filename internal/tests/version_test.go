@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/repository"
+	"Calendar/internal/service"
+	"context"
+	errors1 "errors"
+	"testing"
+)
+
+// VersionedRepository keeps the current version of a single event.
+type VersionedRepository struct {
+	repository.CalendarRepositoryInterface
+	version int64
+}
+
+func (m *VersionedRepository) UpdateEvent(event *models.Event) error {
+	if event.Version != m.version {
+		return &repository.VersionConflictError{EventID: event.EventID, Version: m.version}
+	}
+	m.version++
+	event.Version = m.version
+	return nil
+}
+
+func (m *VersionedRepository) DeleteEvent(eventID string, version int64) error {
+	if version != m.version {
+		return &repository.VersionConflictError{EventID: eventID, Version: m.version}
+	}
+	return nil
+}
+
+func TestCalendarService_Versions(t *testing.T) {
+	repo := &VersionedRepository{version: 3}
+	srv := service.NewCalendarService(context.Background(), service.QuotaConfig{}, repo)
+
+	first := &models.Event{EventID: "1", UserID: "1", Event: "event", Date: "2025-09-29", Version: 3}
+	if err := srv.UpdateEvent(first); err != nil {
+		t.Fatalf("error = %v", err)
+	}
+	if first.Version != 4 {
+		t.Errorf("version = %d, want 4", first.Version)
+	}
+
+	second := &models.Event{EventID: "1", UserID: "1", Event: "stale", Date: "2025-09-29", Version: 3}
+	err := srv.UpdateEvent(second)
+	var preconditionErr *errors.PreconditionFailedError
+	if !errors1.As(err, &preconditionErr) || preconditionErr.Version != 4 {
+		t.Errorf("error = %v, want precondition failed at version 4", err)
+	}
+	if err := srv.DeleteEvent("1", 3); !errors1.As(err, &preconditionErr) {
+		t.Errorf("error = %v, want precondition failed", err)
+	}
+
+	var requiredErr *errors.PreconditionRequiredError
+	if err := srv.UpdateEvent(&models.Event{EventID: "1", UserID: "1", Event: "event", Date: "2025-09-29"}); !errors1.As(err, &requiredErr) {
+		t.Errorf("error = %v, want precondition required", err)
+	}
+	if err := srv.DeleteEvent("1", 0); !errors1.As(err, &requiredErr) {
+		t.Errorf("error = %v, want precondition required", err)
+	}
+	if err := srv.DeleteEvent("1", 4); err != nil {
+		t.Errorf("error = %v", err)
+	}
+}
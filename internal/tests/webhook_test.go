@@ -0,0 +1,247 @@
+package tests
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/notifier"
+	"Calendar/internal/repository"
+	"Calendar/internal/scheduler"
+	"Calendar/internal/service"
+	"Calendar/pkg/logger"
+	"context"
+	errors1 "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type MockWebhookRepository struct {
+	repository.WebhookRepositoryInterface
+}
+
+func (m *MockWebhookRepository) CreateSubscription(sub *models.WebhookSubscription) error {
+	return nil
+}
+
+func TestWebhookService_CreateSubscription(t *testing.T) {
+	ctx := context.Background()
+	srv := service.NewWebhookService(ctx, &MockWebhookRepository{})
+
+	tests := []struct {
+		name string
+		sub  *models.WebhookSubscription
+		err  error
+	}{
+		{
+			name: "valid",
+			sub: &models.WebhookSubscription{
+				UserID:     "1",
+				URL:        "https://example.com/hook",
+				EventTypes: []string{models.EventCreated, models.EventDeleted},
+			},
+			err: nil,
+		},
+		{
+			name: "invalid url",
+			sub: &models.WebhookSubscription{
+				UserID:     "1",
+				URL:        "ftp://example.com",
+				EventTypes: []string{models.EventCreated},
+			},
+			err: &errors.ValidationError{
+				Field:   "url",
+				Message: "must be an http(s) URL",
+			},
+		},
+		{
+			name: "unknown event type",
+			sub: &models.WebhookSubscription{
+				UserID:     "1",
+				URL:        "https://example.com/hook",
+				EventTypes: []string{"event.moved"},
+			},
+			err: &errors.ValidationError{
+				Field:   "event_types",
+				Message: "must contain only event.created, event.updated, event.deleted",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := srv.CreateSubscription(tt.sub)
+			if tt.err == nil {
+				if err != nil {
+					t.Errorf("error = %v, wantErr %v", err, tt.err)
+					return
+				}
+				if sub.Secret == "" || sub.SubscriptionID == "" {
+					t.Errorf("secret and id must be generated, got %+v", sub)
+				}
+				return
+			}
+			var target *errors.ValidationError
+			if errors1.As(err, &target) {
+				if target.Field != tt.err.(*errors.ValidationError).Field ||
+					target.Message != tt.err.(*errors.ValidationError).Message {
+					t.Errorf("error = %v, wantErr %v", err, tt.err)
+				}
+			} else {
+				t.Errorf("error = %v, wantErr %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"id":1,"type":"event.created"}`)
+	sig := notifier.Sign("secret", 1700000000, body)
+	if !notifier.VerifySignature("secret", 1700000000, body, sig) {
+		t.Errorf("signature %s does not verify", sig)
+	}
+	if notifier.VerifySignature("other", 1700000000, body, sig) {
+		t.Errorf("signature verified with a wrong secret")
+	}
+	if notifier.VerifySignature("secret", 1700000001, body, sig) {
+		t.Errorf("signature verified with a wrong timestamp")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 10 * time.Second},
+		{attempt: 2, want: 20 * time.Second},
+		{attempt: 4, want: 80 * time.Second},
+		{attempt: 20, want: time.Hour},
+	}
+	for _, tt := range tests {
+		if got := scheduler.Backoff(10*time.Second, time.Hour, tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// WebhookDeliveries hands out its deliveries on the first claim and records
+// the ones rescheduled.
+type WebhookDeliveries struct {
+	repository.WebhookRepositoryInterface
+	mu         sync.Mutex
+	deliveries []*models.WebhookDelivery
+	retried    []string
+}
+
+func (r *WebhookDeliveries) ClaimDeliveries(limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deliveries := r.deliveries
+	r.deliveries = nil
+	return deliveries, nil
+}
+
+func (r *WebhookDeliveries) RetryDelivery(deliveryID string, nextAttemptAt time.Time, responseStatus int, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retried = append(r.retried, deliveryID)
+	return nil
+}
+
+func TestWebhookWorker_Stop(t *testing.T) {
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- r.Header.Get("X-Calendar-Delivery")
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, err := logger.New(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	repo := &WebhookDeliveries{deliveries: []*models.WebhookDelivery{
+		{DeliveryID: "d1", URL: server.URL, Attempts: 1},
+		{DeliveryID: "d2", URL: server.URL, Attempts: 1},
+	}}
+	cfg := scheduler.WebhookConfig{PollInterval: time.Hour, Timeout: time.Minute, MaxAttempts: 5, StopTimeout: 50 * time.Millisecond}
+	worker := scheduler.NewWebhookWorker(ctx, cfg, repo)
+
+	done := make(chan struct{})
+	go func() {
+		worker.Run()
+		close(done)
+	}()
+	if id := <-started; id != "d1" {
+		t.Fatalf("delivery = %q, want d1", id)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop within the stop timeout")
+	}
+	if len(started) != 0 || len(repo.retried) != 1 {
+		t.Errorf("started %d more, retried %v; want only d1 retried", len(started), repo.retried)
+	}
+}
+
+// OwnedWebhookRepository holds webhook s1 with dead delivery d1, both of
+// user 1.
+type OwnedWebhookRepository struct {
+	repository.WebhookRepositoryInterface
+}
+
+func (m *OwnedWebhookRepository) DeleteSubscription(userID string, subscriptionID string) error {
+	if userID != "1" || subscriptionID != "s1" {
+		return fmt.Errorf("%w with id: %s", repository.ErrWebhookNotFound, subscriptionID)
+	}
+	return nil
+}
+
+func (m *OwnedWebhookRepository) GetDeliveries(userID string, subscriptionID string, status string, limit int) ([]*models.WebhookDelivery, error) {
+	if userID != "1" || subscriptionID != "s1" {
+		return nil, fmt.Errorf("%w with id: %s", repository.ErrWebhookNotFound, subscriptionID)
+	}
+	return []*models.WebhookDelivery{{DeliveryID: "d1", SubscriptionID: "s1"}}, nil
+}
+
+func (m *OwnedWebhookRepository) Redeliver(userID string, deliveryID string) error {
+	if userID != "1" || deliveryID != "d1" {
+		return fmt.Errorf("%w with id: %s", repository.ErrDeliveryNotFound, deliveryID)
+	}
+	return nil
+}
+
+func TestWebhookService_Ownership(t *testing.T) {
+	srv := service.NewWebhookService(context.Background(), &OwnedWebhookRepository{})
+
+	tests := []struct {
+		name string
+		call func(userID string) error
+	}{
+		{name: "delete", call: func(userID string) error { return srv.DeleteSubscription(userID, "s1") }},
+		{name: "deliveries", call: func(userID string) error { _, err := srv.GetDeliveries(userID, "s1", "", ""); return err }},
+		{name: "redeliver", call: func(userID string) error { return srv.Redeliver(userID, "d1") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call("1"); err != nil {
+				t.Errorf("owner error = %v", err)
+			}
+			var notFound *errors.NotFoundError
+			if err := tt.call("2"); !errors1.As(err, &notFound) {
+				t.Errorf("other user error = %v, want not found", err)
+			}
+			if err := tt.call(""); !isValidationError("user_id")(err) {
+				t.Errorf("error without user = %v, want validation error on user_id", err)
+			}
+		})
+	}
+}
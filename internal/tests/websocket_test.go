@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"Calendar/internal/config"
+	"Calendar/internal/eventbus"
+	"Calendar/internal/models"
+	"Calendar/internal/transport"
+	"Calendar/pkg/logger"
+	"context"
+	errors1 "errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type wsTestMessage struct {
+	Type       string              `json:"type"`
+	CalendarID string              `json:"calendar_id,omitempty"`
+	Change     *models.DomainEvent `json:"change,omitempty"`
+	Viewers    []string            `json:"viewers,omitempty"`
+	Message    string              `json:"message,omitempty"`
+}
+
+// newTestWSServer serves the router with changes published on the returned
+// bus and returns a function connecting a socket as userID.
+func newTestWSServer(t *testing.T) (*eventbus.InMemoryBus, func(userID string) *websocket.Conn) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ctx, err := logger.New(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := &FakeChangeService{bus: eventbus.NewInMemoryBus()}
+	server := httptest.NewServer(transport.NewCalendarServer(ctx, &config.Config{}, &FakeCalendarService{}, nil, changes, nil).Router())
+	t.Cleanup(server.Close)
+	return changes.bus, func(userID string) *websocket.Conn {
+		t.Helper()
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/v1/ws?user_id="+userID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+}
+
+func sendWS(t *testing.T, conn *websocket.Conn, msg wsTestMessage) {
+	t.Helper()
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func receiveWS(t *testing.T, conn *websocket.Conn) wsTestMessage {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg wsTestMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read error = %v", err)
+	}
+	return msg
+}
+
+// expectWS reads the next message and checks its type and calendar.
+func expectWS(t *testing.T, conn *websocket.Conn, msgType, calendarID string) wsTestMessage {
+	t.Helper()
+	msg := receiveWS(t, conn)
+	if msg.Type != msgType || msg.CalendarID != calendarID {
+		t.Fatalf("message = %+v, want %s of calendar %q", msg, msgType, calendarID)
+	}
+	return msg
+}
+
+func TestWebSocket_Subscriptions(t *testing.T) {
+	bus, connect := newTestWSServer(t)
+	conn := connect("1")
+	ctx := context.Background()
+
+	sendWS(t, conn, wsTestMessage{Type: "subscribe", CalendarID: "2"})
+	expectWS(t, conn, "subscribed", "2")
+	expectWS(t, conn, "presence", "2")
+	for _, change := range []*models.DomainEvent{
+		{Seq: 1, Type: models.EventCreated, EventID: "e1", UserID: "3", After: &models.Event{EventID: "e1", UserID: "3"}},
+		{Seq: 2, Type: models.EventCreated, EventID: "e2", UserID: "2", After: &models.Event{EventID: "e2", UserID: "2", Visibility: models.VisibilityPrivate}},
+		{Seq: 3, Type: models.EventCreated, EventID: "e3", UserID: "2", After: &models.Event{EventID: "e3", UserID: "2", Event: "retro"}},
+	} {
+		bus.Publish(ctx, change)
+	}
+	msg := expectWS(t, conn, "change", "2")
+	if msg.Change.EventID != "e3" || msg.Change.After.Event != "retro" {
+		t.Errorf("change = %+v, want e3 with unwatched calendars and private events skipped", msg.Change)
+	}
+
+	sendWS(t, conn, wsTestMessage{Type: "unsubscribe", CalendarID: "2"})
+	expectWS(t, conn, "unsubscribed", "2")
+	bus.Publish(ctx, &models.DomainEvent{Seq: 4, Type: models.EventDeleted, EventID: "e3", UserID: "2", Before: &models.Event{EventID: "e3", UserID: "2"}})
+	sendWS(t, conn, wsTestMessage{Type: "ping"})
+	expectWS(t, conn, "pong", "")
+
+	sendWS(t, conn, wsTestMessage{Type: "subscribe"})
+	if msg := expectWS(t, conn, "error", ""); msg.Message == "" {
+		t.Errorf("error = %+v, want a message", msg)
+	}
+}
+
+func TestWebSocket_Presence(t *testing.T) {
+	_, connect := newTestWSServer(t)
+	alice := connect("1")
+	sendWS(t, alice, wsTestMessage{Type: "subscribe", CalendarID: "team"})
+	expectWS(t, alice, "subscribed", "team")
+	if msg := expectWS(t, alice, "presence", "team"); !slices.Equal(msg.Viewers, []string{"1"}) {
+		t.Errorf("viewers = %v, want [1]", msg.Viewers)
+	}
+
+	bob := connect("2")
+	sendWS(t, bob, wsTestMessage{Type: "subscribe", CalendarID: "team"})
+	expectWS(t, bob, "subscribed", "team")
+	if msg := expectWS(t, bob, "presence", "team"); !slices.Equal(msg.Viewers, []string{"1", "2"}) {
+		t.Errorf("viewers seen by the joining client = %v, want [1 2]", msg.Viewers)
+	}
+	if msg := expectWS(t, alice, "presence", "team"); !slices.Equal(msg.Viewers, []string{"1", "2"}) {
+		t.Errorf("viewers after a join = %v, want [1 2]", msg.Viewers)
+	}
+
+	bob.Close()
+	if msg := expectWS(t, alice, "presence", "team"); !slices.Equal(msg.Viewers, []string{"1"}) {
+		t.Errorf("viewers after a leave = %v, want [1]", msg.Viewers)
+	}
+}
+
+func TestWebSocket_SlowClient(t *testing.T) {
+	bus, connect := newTestWSServer(t)
+	conn := connect("1")
+	sendWS(t, conn, wsTestMessage{Type: "subscribe", CalendarID: "1"})
+	expectWS(t, conn, "subscribed", "1")
+
+	closed := make(chan error, 1)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				closed <- err
+				return
+			}
+		}
+	}()
+	// The bus publishes far faster than a socket is written, so the
+	// subscription buffer overflows.
+	deadline := time.Now().Add(5 * time.Second)
+	for seq := int64(1); ; seq++ {
+		bus.Publish(context.Background(), &models.DomainEvent{Seq: seq, Type: models.EventCreated, EventID: "e", UserID: "1"})
+		select {
+		case err := <-closed:
+			var closeErr *websocket.CloseError
+			if !errors1.As(err, &closeErr) || closeErr.Code != websocket.CloseTryAgainLater {
+				t.Errorf("close = %v, want try again later", err)
+			}
+			return
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("slow client was not disconnected")
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package transport
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// adminOnly lets through only requests whose X-API-Key header is one of the
+// configured admin keys. Requests without a key get 401, requests with any
+// other key 403; with no admin keys configured the routes are closed.
+func (s *CalendarServer) adminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{
+				Error:   "unauthorized",
+				Message: "an admin X-API-Key is required",
+			})
+			return
+		}
+		if !isKnownKey(s.cfg.AdminKeys, key) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{
+				Error:   "forbidden",
+				Message: "X-API-Key is not an admin key",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// isKnownKey tells whether key is one of keys, in time independent of where
+// they differ.
+func isKnownKey(keys []string, key string) bool {
+	for _, known := range keys {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
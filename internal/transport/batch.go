@@ -0,0 +1,59 @@
+package transport
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// batchResult reports one operation with the status and body it would have
+// got as a single request.
+type batchResult struct {
+	Status int            `json:"status"`
+	Event  *models.Event  `json:"event,omitempty"`
+	Error  *ErrorResponse `json:"error,omitempty"`
+}
+
+var batchSuccessStatus = map[string]int{
+	models.BatchCreate: http.StatusCreated,
+	models.BatchUpdate: http.StatusOK,
+	models.BatchDelete: http.StatusNoContent,
+}
+
+// batchHandler applies a list of create, update and delete operations. The
+// batch itself succeeds with 200 once it could be run; whether the
+// operations did is told by their results.
+func (s *CalendarServer) batchHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		var request *models.Batch
+		if err := c.ShouldBindJSON(&request); err != nil || request == nil {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "request_body",
+				Message: "invalid JSON format",
+			})
+			return
+		}
+		results, err := s.actingService(c).ApplyBatch(request)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		response := make([]batchResult, len(results))
+		for i, result := range results {
+			if result.Err != nil {
+				status, body := s.errorResponse(result.Err)
+				response[i] = batchResult{Status: status, Error: &body}
+				continue
+			}
+			response[i] = batchResult{Status: batchSuccessStatus[request.Operations[i].Op], Event: result.Event}
+		}
+		c.JSON(http.StatusOK, gin.H{"mode": request.Mode, "results": response})
+	}
+}
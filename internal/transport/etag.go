@@ -0,0 +1,32 @@
+package transport
+
+import (
+	"Calendar/internal/errors"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+)
+
+// etag formats an event version as a strong entity tag.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// expectedVersion returns the event version named by the If-Match header. If
+// the header is missing, the version sent in the request body is used.
+func expectedVersion(c *gin.Context, bodyVersion int64) (int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return bodyVersion, nil
+	}
+	tag, quoted := strings.CutPrefix(header, `"`)
+	tag, closed := strings.CutSuffix(tag, `"`)
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if !quoted || !closed || err != nil || version <= 0 {
+		return 0, &errors.ValidationError{
+			Field:   "If-Match",
+			Message: "must be a single ETag returned by the server",
+		}
+	}
+	return version, nil
+}
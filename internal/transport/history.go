@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/internal/service"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// revertRequest names the revision to go back to and the version it replaces.
+type revertRequest struct {
+	Revision int   `json:"revision"`
+	Version  int64 `json:"version"`
+}
+
+// actingService returns the service recording the user named by the user_id
+// query parameter as the author of its changes.
+func (s *CalendarServer) actingService(c *gin.Context) service.CalendarServiceInterface {
+	return service.WithActor(s.srv, c.Query("user_id"))
+}
+
+func (s *CalendarServer) historyHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		entries, err := s.srv.GetHistory(c.Param("event_id"), c.Query("user_id"))
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"history": entries})
+	}
+}
+
+func (s *CalendarServer) auditHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		page, err := s.srv.Audit(&models.AuditParams{
+			Actor:  c.Query("actor"),
+			UserID: c.Query("user_id"),
+			From:   c.Query("from"),
+			To:     c.Query("to"),
+			Cursor: c.Query("cursor"),
+			Limit:  c.Query("limit"),
+		})
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+// revertEventHandler puts the event back into the state of an earlier
+// revision for its owner in user_id. The expected version comes from If-Match
+// or the request body.
+func (s *CalendarServer) revertEventHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		var request *revertRequest
+		if err := c.ShouldBindJSON(&request); err != nil || request == nil {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "request_body",
+				Message: "invalid JSON format",
+			})
+			return
+		}
+		version, err := expectedVersion(c, request.Version)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		event, err := s.actingService(c).RevertEvent(c.Param("event_id"), c.Query("user_id"), request.Revision, version)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.Header("ETag", etag(event.Version))
+		c.JSON(http.StatusOK, event)
+	}
+}
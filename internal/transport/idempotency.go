@@ -0,0 +1,130 @@
+package transport
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"Calendar/pkg/logger"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+)
+
+// replayedHeaders are the response headers stored with an idempotent
+// response.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// recordingWriter keeps a copy of the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent makes a mutating request safe to retry when it carries an
+// Idempotency-Key header. Keys are scoped to the caller, so clients that pick
+// the same key don't see each other's responses. The first response with the
+// key is stored and sent again for retries of the same request; server errors
+// aren't stored, so a retry after one runs the request again.
+func (s *CalendarServer) idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || s.idempotency == nil {
+			c.Next()
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "request_body",
+				Message: "can't be read",
+			})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := idempotencyScope(c)
+		stored, err := s.idempotency.Begin(scope, key, requestFingerprint(c.Request, body))
+		if err != nil {
+			s.handleError(c, err)
+			c.Abort()
+			return
+		}
+		if stored != nil {
+			for name, value := range stored.Header {
+				c.Header(name, value)
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Status(stored.Status)
+			c.Writer.Write(stored.Body)
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			if err := s.idempotency.Abandon(scope, key); err != nil {
+				logger.GetLoggerFromCtx(s.ctx).Error("error releasing idempotency key", zap.Error(err))
+			}
+			return
+		}
+		header := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := writer.Header().Get(name); value != "" {
+				header[name] = value
+			}
+		}
+		err = s.idempotency.Complete(&models.IdempotencyRecord{
+			Scope:  scope,
+			Key:    key,
+			Status: writer.Status(),
+			Header: header,
+			Body:   writer.body.Bytes(),
+		})
+		if err != nil {
+			logger.GetLoggerFromCtx(s.ctx).Error("error storing idempotent response", zap.Error(err))
+		}
+	}
+}
+
+// idempotencyScope identifies the caller that owns an Idempotency-Key by its
+// X-API-Key header, which is stored hashed, its user_id query parameter or
+// else its IP address.
+func idempotencyScope(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		hash := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(hash[:])
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// requestFingerprint identifies a request by its method, URL, expected
+// version and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{r.Method, r.URL.RequestURI(), r.Header.Get("If-Match")} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,26 @@
+package transport
+
+import (
+	_ "embed"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// openAPISpec describes every route of Router. TestOpenAPI checks it against
+// the routes and the handlers' responses.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+//go:embed docs.html
+var docsPage []byte
+
+// registerDocs serves the OpenAPI document and a page rendering it.
+func (s *CalendarServer) registerDocs(router *gin.Engine) {
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec)
+	})
+	router.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+	})
+}
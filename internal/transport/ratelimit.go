@@ -0,0 +1,85 @@
+package transport
+
+import (
+	"Calendar/internal/ratelimit"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// rateLimited answers 429 to clients that send more requests than the
+// configured limits allow. Every response carries the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers of the tightest limit of its
+// client, and 429 responses a Retry-After.
+func (s *CalendarServer) rateLimited() gin.HandlerFunc {
+	cfg := s.cfg.RateLimit
+	limiter := ratelimit.NewLimiter(cfg.Window)
+	return func(c *gin.Context) {
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+		var shown *ratelimit.Result
+		now := time.Now()
+		// The IP bucket comes first, so a client rejected by it can't add
+		// buckets by making up user ids.
+		for _, limit := range rateLimits(c, cfg) {
+			if limit.limit <= 0 {
+				continue
+			}
+			result := limiter.Allow(limit.key, limit.limit, now)
+			if shown == nil || !result.Allowed || result.Remaining < shown.Remaining {
+				shown = &result
+			}
+			if !result.Allowed {
+				break
+			}
+		}
+		if shown == nil {
+			c.Next()
+			return
+		}
+		c.Header("RateLimit-Limit", strconv.Itoa(shown.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(shown.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(shown.Reset))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%s", shown.Limit, ceilSeconds(cfg.Window)))
+		if !shown.Allowed {
+			retryAfter := ceilSeconds(shown.RetryAfter)
+			c.Header("Retry-After", retryAfter)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{
+				Error:   "rate_limited",
+				Message: "too many requests, retry after " + retryAfter + " seconds",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimit is a bucket a request takes a token from.
+type rateLimit struct {
+	key   string
+	limit int
+}
+
+// rateLimits returns the buckets of the client of the request. A configured
+// X-API-Key has a bucket of its own; any other request takes from the bucket
+// of its IP address and then from that of its user_id query parameter.
+// Unknown API keys are ignored.
+func rateLimits(c *gin.Context, cfg ratelimit.Config) []rateLimit {
+	if key := c.GetHeader("X-API-Key"); key != "" && isKnownKey(cfg.APIKeys, key) {
+		return []rateLimit{{key: "key:" + key, limit: cfg.APIKeyLimit}}
+	}
+	limits := []rateLimit{{key: "ip:" + c.ClientIP(), limit: cfg.IPLimit}}
+	if userID := c.Query("user_id"); userID != "" {
+		limits = append(limits, rateLimit{key: "user:" + userID, limit: cfg.UserLimit})
+	}
+	return limits
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"encoding/csv"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (s *CalendarServer) reportHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodGet {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		format := c.DefaultQuery("format", models.FormatJSON)
+		if format != models.FormatJSON && format != models.FormatCSV {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "format",
+				Message: "must be json or csv",
+			})
+			return
+		}
+		report, err := s.srv.GetReport(&models.ReportParams{
+			ViewerID:    c.Query("user_id"),
+			CalendarIDs: queryList(c, "calendar_ids"),
+			From:        c.Query("from"),
+			To:          c.Query("to"),
+			Bucket:      c.Query("bucket"),
+			GroupBy:     c.Query("group_by"),
+		})
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		if format == models.FormatJSON {
+			c.JSON(http.StatusOK, report)
+			return
+		}
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="report.csv"`)
+		c.Status(http.StatusOK)
+		w := csv.NewWriter(c.Writer)
+		_ = w.Write([]string{"bucket", report.GroupBy, "hours", "events"})
+		for _, row := range report.Rows {
+			_ = w.Write([]string{row.Bucket, csvSafe(row.Key), strconv.FormatFloat(row.Hours, 'f', 2, 64), strconv.Itoa(row.Events)})
+		}
+		w.Flush()
+	}
+}
+
+// csvSafe keeps spreadsheets from evaluating user-provided values as formulas.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
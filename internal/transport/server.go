@@ -9,39 +9,111 @@ import (
 	"context"
 	errors1 "errors"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 type CalendarServer struct {
-	ctx context.Context
-	cfg *config.Config
-	srv service.CalendarServiceInterface
+	ctx         context.Context
+	cfg         *config.Config
+	srv         service.CalendarServiceInterface
+	webhooks    service.WebhookServiceInterface
+	changes     service.ChangeServiceInterface
+	idempotency service.IdempotencyServiceInterface
+	presence    *presenceHub
+	upgrader    websocket.Upgrader
+	httpServer  *http.Server
+	done        chan struct{}
+	closeOnce   sync.Once
 }
 
-func NewCalendarServer(ctx context.Context, cfg *config.Config, srv service.CalendarServiceInterface) *CalendarServer {
+func NewCalendarServer(ctx context.Context, cfg *config.Config, srv service.CalendarServiceInterface, webhooks service.WebhookServiceInterface, changes service.ChangeServiceInterface, idempotency service.IdempotencyServiceInterface) *CalendarServer {
 	return &CalendarServer{
-		ctx: ctx,
-		cfg: cfg,
-		srv: srv,
+		ctx:         ctx,
+		cfg:         cfg,
+		srv:         srv,
+		webhooks:    webhooks,
+		changes:     changes,
+		idempotency: idempotency,
+		presence:    newPresenceHub(),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		},
+		done: make(chan struct{}),
 	}
 }
 
 func (s *CalendarServer) Run() error {
-	router := gin.Default()
-	router.Use(s.Logger())
 	logger.GetLoggerFromCtx(s.ctx).Info("gin framework is running")
-	api := router.Group("/api/v1")
+	s.httpServer = &http.Server{
+		Addr:    s.cfg.Host + ":" + s.cfg.Port,
+		Handler: s.Router(),
+	}
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors1.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Router returns the HTTP handler serving every API version.
+func (s *CalendarServer) Router() *gin.Engine {
+	router := gin.Default()
+	// The client IP keys the rate limits and idempotency scopes, so it is only
+	// read from X-Forwarded-For when the request came through a trusted proxy.
+	if err := router.SetTrustedProxies(s.cfg.TrustedProxies); err != nil {
+		logger.GetLoggerFromCtx(s.ctx).Error("invalid trusted proxies, trusting none", zap.Error(err))
+		_ = router.SetTrustedProxies(nil)
+	}
+	router.Use(s.Logger(), s.instrumented(), s.rateLimited())
+	api := router.Group("/api/v1", deprecatedAPI("/api/v2"))
 	{
-		api.POST("/create_event", s.createEventHandler())
-		api.POST("/update_event", s.updateEventHandler())
-		api.POST("/delete_event", s.deleteEventHandler())
+		api.POST("/create_event", s.idempotent(), s.createEventHandler())
+		api.GET("/event", s.getEventHandler())
+		api.POST("/update_event", s.idempotent(), s.updateEventHandler())
+		api.PATCH("/events/:event_id", s.idempotent(), s.patchEventHandler())
+		api.POST("/delete_event", s.idempotent(), s.deleteEventHandler())
 		api.GET("/events_for_day", s.getEventsForDayEventHandler())
 		api.GET("/events_for_week", s.getEventsForWeekEventHandler())
 		api.GET("/events_for_month", s.getEventsForMonthEventHandler())
+		api.GET("/events", s.queryEventsHandler())
+		api.GET("/user_settings", s.getUserSettingsHandler())
+		api.POST("/update_user_settings", s.updateUserSettingsHandler())
+		api.GET("/search", s.searchEventsHandler())
+		api.GET("/report", s.reportHandler())
+		api.GET("/tags", s.getTagsHandler())
+		api.POST("/rename_tag", s.renameTagHandler())
+		api.POST("/merge_tags", s.mergeTagsHandler())
+		api.POST("/delete_tag", s.deleteTagHandler())
+		api.POST("/create_webhook", s.createWebhookHandler())
+		api.POST("/delete_webhook", s.deleteWebhookHandler())
+		api.GET("/webhooks", s.getWebhooksHandler())
+		api.GET("/webhook_deliveries", s.getWebhookDeliveriesHandler())
+		api.GET("/webhook_dead_letters", s.getWebhookDeadLettersHandler())
+		api.POST("/redeliver_webhook", s.redeliverWebhookHandler())
+		api.GET("/sync", s.syncEventsHandler())
+		api.GET("/events_stream", s.streamEventsHandler())
+		api.GET("/ws", s.websocketHandler())
+	}
+	s.registerV2(router.Group("/api/v2"))
+	s.registerDocs(router)
+	s.registerMetrics(router)
+	return router
+}
+
+// Shutdown stops accepting connections, ends open streams and waits for
+// in-flight requests.
+func (s *CalendarServer) Shutdown(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.done) })
+	if s.httpServer == nil {
+		return nil
 	}
-	return router.Run(s.cfg.Host + ":" + s.cfg.Port)
+	return s.httpServer.Shutdown(ctx)
 }
 
 func (s *CalendarServer) Logger() gin.HandlerFunc {
@@ -70,12 +142,36 @@ func (s *CalendarServer) createEventHandler() gin.HandlerFunc {
 			})
 			return
 		}
-		id, err := s.srv.CreateEvent(request)
+		id, err := s.actingService(c).CreateEvent(request)
 		if err != nil {
 			s.handleError(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"result": "Event created successfully", "id": id})
+		c.Header("ETag", etag(request.Version))
+		c.JSON(http.StatusOK, gin.H{"result": "Event created successfully", "id": id, "version": request.Version, "event": request})
+	}
+}
+
+// getEventHandler returns the event as the user in user_id may see it.
+func (s *CalendarServer) getEventHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodGet {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		event, err := s.srv.GetEvent(c.Query("event_id"), c.Query("user_id"))
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.Header("ETag", etag(event.Version))
+		c.JSON(http.StatusOK, gin.H{"event": event})
 	}
 }
 
@@ -99,12 +195,63 @@ func (s *CalendarServer) updateEventHandler() gin.HandlerFunc {
 			})
 			return
 		}
-		err := s.srv.UpdateEvent(request)
+		version, err := expectedVersion(c, request.Version)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		request.Version = version
+		err = s.actingService(c).UpdateEvent(request)
 		if err != nil {
 			s.handleError(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"result": "Event updated successfully"})
+		c.Header("ETag", etag(request.Version))
+		c.JSON(http.StatusOK, gin.H{"result": "Event updated successfully", "version": request.Version, "event": request})
+	}
+}
+
+// patchEventHandler updates the fields of the event present in a JSON merge
+// patch and returns the updated event.
+func (s *CalendarServer) patchEventHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodPatch {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		if contentType := c.ContentType(); contentType != "application/merge-patch+json" && contentType != "application/json" {
+			c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{
+				Error:   "unsupported_media_type",
+				Message: "content type must be application/merge-patch+json",
+			})
+			return
+		}
+		patch, err := c.GetRawData()
+		if err != nil {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "request_body",
+				Message: "invalid JSON format",
+			})
+			return
+		}
+		version, err := expectedVersion(c, 0)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		event, err := s.actingService(c).PatchEvent(c.Param("event_id"), patch, version)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.Header("ETag", etag(event.Version))
+		c.JSON(http.StatusOK, gin.H{"result": "Event updated successfully", "event": event})
 	}
 }
 
@@ -128,7 +275,12 @@ func (s *CalendarServer) deleteEventHandler() gin.HandlerFunc {
 			})
 			return
 		}
-		err := s.srv.DeleteEvent(request.ID)
+		version, err := expectedVersion(c, request.Version)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		err = s.actingService(c).DeleteEvent(request.ID, version)
 		if err != nil {
 			s.handleError(c, err)
 			return
@@ -151,7 +303,7 @@ func (s *CalendarServer) getEventsForDayEventHandler() gin.HandlerFunc {
 		}
 		userID := c.Query("user_id")
 		date := c.Query("date")
-		events, err := s.srv.GetEventsForDay(userID, date)
+		events, err := s.srv.GetEventsForDay(userID, date, queryList(c, "tags"))
 		if err != nil {
 			s.handleError(c, err)
 			return
@@ -174,7 +326,8 @@ func (s *CalendarServer) getEventsForWeekEventHandler() gin.HandlerFunc {
 		}
 		userID := c.Query("user_id")
 		date := c.Query("date")
-		events, err := s.srv.GetEventsForWeek(userID, date)
+		window := c.Query("window")
+		events, err := s.srv.GetEventsForWeek(userID, date, window, queryList(c, "tags"))
 		if err != nil {
 			s.handleError(c, err)
 			return
@@ -197,7 +350,8 @@ func (s *CalendarServer) getEventsForMonthEventHandler() gin.HandlerFunc {
 		}
 		userID := c.Query("user_id")
 		date := c.Query("date")
-		events, err := s.srv.GetEventsForMonth(userID, date)
+		window := c.Query("window")
+		events, err := s.srv.GetEventsForMonth(userID, date, window, queryList(c, "tags"))
 		if err != nil {
 			s.handleError(c, err)
 			return
@@ -206,6 +360,146 @@ func (s *CalendarServer) getEventsForMonthEventHandler() gin.HandlerFunc {
 	}
 }
 
+func (s *CalendarServer) getUserSettingsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodGet {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		settings, err := s.srv.GetUserSettings(c.Query("user_id"))
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"settings": settings})
+	}
+}
+
+func (s *CalendarServer) updateUserSettingsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodPost {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		var request *models.UserSettings
+		if err := c.ShouldBindJSON(&request); err != nil {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "request_body",
+				Message: "invalid JSON format",
+			})
+			return
+		}
+		err := s.srv.UpdateUserSettings(request)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"result": "Settings updated successfully"})
+	}
+}
+
+func (s *CalendarServer) queryEventsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodGet {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		page, err := s.srv.QueryEvents(&models.EventQueryParams{
+			ViewerID:    c.Query("user_id"),
+			CalendarIDs: queryList(c, "calendar_ids"),
+			From:        c.Query("from"),
+			To:          c.Query("to"),
+			Text:        c.Query("q"),
+			Statuses:    queryList(c, "status"),
+			Tags:        queryList(c, "tags"),
+			Sort:        c.Query("sort"),
+			Cursor:      c.Query("cursor"),
+			Limit:       c.Query("limit"),
+		})
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+func (s *CalendarServer) searchEventsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodGet {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		results, err := s.srv.SearchEvents(c.Query("user_id"), queryList(c, "calendar_ids"), c.Query("q"), c.Query("lang"), c.Query("limit"))
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"results": results})
+	}
+}
+
+// queryList accepts both repeated (?a=1&a=2) and comma separated (?a=1,2)
+// query parameters.
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, value := range c.QueryArray(key) {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+func (s *CalendarServer) syncEventsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodGet {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		userID := c.Query("user_id")
+		token := c.Query("sync_token")
+		result, err := s.srv.Sync(userID, token)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
 type ErrorResponse struct {
 	Error   string            `json:"error"`
 	Message string            `json:"message,omitempty"`
@@ -213,26 +507,74 @@ type ErrorResponse struct {
 }
 
 func (s *CalendarServer) handleError(c *gin.Context, err error) {
+	var preconditionErr *errors.PreconditionFailedError
+	if errors1.As(err, &preconditionErr) {
+		c.Header("ETag", etag(preconditionErr.Version))
+	}
+	c.JSON(s.errorResponse(err))
+}
+
+// errorResponse returns the status and body an error is reported with.
+func (s *CalendarServer) errorResponse(err error) (int, ErrorResponse) {
 	var validationErr *errors.ValidationError
 	var businessErr *errors.BusinessError
+	var preconditionErr *errors.PreconditionFailedError
+	var requiredErr *errors.PreconditionRequiredError
+	var notFoundErr *errors.NotFoundError
+	var abortedErr *errors.AbortedError
+	var conflictErr *errors.ConflictError
+	var quotaErr *errors.QuotaExceededError
 
 	switch {
 	case errors1.As(err, &validationErr):
-		c.JSON(http.StatusBadRequest, ErrorResponse{
+		return http.StatusBadRequest, ErrorResponse{
 			Error:   "validation_error",
 			Message: validationErr.Error(),
 			Details: map[string]string{validationErr.Field: validationErr.Message},
-		})
+		}
+	case errors1.As(err, &notFoundErr):
+		return http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: notFoundErr.Error(),
+		}
+	case errors1.As(err, &preconditionErr):
+		return http.StatusPreconditionFailed, ErrorResponse{
+			Error:   "precondition_failed",
+			Message: preconditionErr.Error(),
+			Details: map[string]string{"version": strconv.FormatInt(preconditionErr.Version, 10)},
+		}
+	case errors1.As(err, &requiredErr):
+		return http.StatusPreconditionRequired, ErrorResponse{
+			Error:   "precondition_required",
+			Message: requiredErr.Error(),
+		}
+	case errors1.As(err, &abortedErr):
+		return http.StatusFailedDependency, ErrorResponse{
+			Error:   "aborted",
+			Message: abortedErr.Error(),
+			Details: map[string]string{"failed_operation": strconv.Itoa(abortedErr.Index)},
+		}
+	case errors1.As(err, &quotaErr):
+		return http.StatusForbidden, ErrorResponse{
+			Error:   "quota_exceeded",
+			Message: quotaErr.Error(),
+			Details: map[string]string{"limit": strconv.Itoa(quotaErr.Limit)},
+		}
+	case errors1.As(err, &conflictErr):
+		return http.StatusConflict, ErrorResponse{
+			Error:   "conflict",
+			Message: conflictErr.Error(),
+		}
 	case errors1.As(err, &businessErr):
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+		return http.StatusServiceUnavailable, ErrorResponse{
 			Error:   "business_error",
 			Message: businessErr.Error(),
-		})
+		}
 	default:
 		logger.GetLoggerFromCtx(s.ctx).Error("Internal server error", zap.Any("error", err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		return http.StatusInternalServerError, ErrorResponse{
 			Error:   "internal_server_error",
 			Message: "An unexpected error occurred",
-		})
+		}
 	}
 }
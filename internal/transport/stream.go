@@ -0,0 +1,102 @@
+package transport
+
+import (
+	"Calendar/internal/models"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	streamBuffer    = 256
+	streamHeartbeat = 15 * time.Second
+	streamRetry     = 3 * time.Second
+)
+
+// streamEventsHandler pushes changes of the user's calendar as Server-Sent
+// Events. The SSE id is the change's seq, which the relay publishes in commit
+// order, so a reconnecting client that sends Last-Event-ID first receives
+// everything it missed.
+func (s *CalendarServer) streamEventsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodGet {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		userID := c.Query("user_id")
+		lastEventID := c.GetHeader("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = c.Query("last_event_id")
+		}
+		// Subscribe before reading the change log so that a change published
+		// in between is not lost; duplicates are skipped by seq below.
+		sub := s.changes.Subscribe("sse", streamBuffer, func(event *models.DomainEvent) bool {
+			return event.UserID == userID
+		})
+		defer sub.Close()
+		changes, err := s.changes.GetChangesSince(userID, lastEventID)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		sent, _ := strconv.ParseInt(lastEventID, 10, 64)
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds())
+		for _, change := range changes {
+			if err := writeSSE(c, change); err != nil {
+				return
+			}
+			sent = change.Seq
+		}
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-s.done:
+				return
+			case <-sub.Lagged:
+				return
+			case change := <-sub.C:
+				if change.Seq <= sent {
+					continue
+				}
+				if err := writeSSE(c, change); err != nil {
+					return
+				}
+				sent = change.Seq
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeSSE(c *gin.Context, change *models.DomainEvent) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", change.Seq, change.Type, data)
+	return err
+}
@@ -0,0 +1,116 @@
+package transport
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func (s *CalendarServer) getTagsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodGet {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		tags, err := s.srv.GetTags(c.Query("user_id"))
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"tags": tags})
+	}
+}
+
+func (s *CalendarServer) renameTagHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodPost {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		var request *models.Tag
+		if err := c.ShouldBindJSON(&request); err != nil {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "request_body",
+				Message: "invalid JSON format",
+			})
+			return
+		}
+		err := s.actingService(c).RenameTag(request)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"result": "Tag renamed successfully"})
+	}
+}
+
+func (s *CalendarServer) mergeTagsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodPost {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		var request *models.TagMerge
+		if err := c.ShouldBindJSON(&request); err != nil {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "request_body",
+				Message: "invalid JSON format",
+			})
+			return
+		}
+		err := s.actingService(c).MergeTags(request)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"result": "Tags merged successfully"})
+	}
+}
+
+func (s *CalendarServer) deleteTagHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodPost {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		var request *models.Tag
+		if err := c.ShouldBindJSON(&request); err != nil {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "request_body",
+				Message: "invalid JSON format",
+			})
+			return
+		}
+		err := s.actingService(c).DeleteTag(request.UserID, request.TagID)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"result": "Tag deleted successfully"})
+	}
+}
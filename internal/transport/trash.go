@@ -0,0 +1,41 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func (s *CalendarServer) trashHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		events, err := s.srv.GetTrash(c.Param("user_id"), c.Query("limit"))
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"events": events})
+	}
+}
+
+func (s *CalendarServer) restoreEventHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		event, err := s.actingService(c).RestoreEvent(c.Param("event_id"))
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.Header("ETag", etag(event.Version))
+		c.JSON(http.StatusOK, event)
+	}
+}
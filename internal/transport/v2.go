@@ -0,0 +1,201 @@
+package transport
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/graph"
+	"Calendar/internal/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// apiV1Deprecation is the Deprecation header value of /api/v1, the moment
+// /api/v2 became available.
+var apiV1Deprecation = "@" + strconv.FormatInt(time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC).Unix(), 10)
+
+// deprecatedAPI marks every response of the group as deprecated and points
+// clients to its successor.
+func deprecatedAPI(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", apiV1Deprecation)
+		c.Header("Link", "<"+successor+">; rel=\"successor-version\"")
+		c.Next()
+	}
+}
+
+// registerV2 adds the resource-oriented API. It shares the service layer with
+// /api/v1 and answers with the resources themselves.
+func (s *CalendarServer) registerV2(api *gin.RouterGroup) {
+	api.GET("/events", s.queryEventsHandler())
+	api.POST("/events", s.idempotent(), s.createEventV2Handler())
+	api.POST("/events/batch", s.idempotent(), s.batchHandler())
+	api.GET("/events/:event_id", s.getEventV2Handler())
+	api.PUT("/events/:event_id", s.idempotent(), s.putEventV2Handler())
+	api.PATCH("/events/:event_id", s.idempotent(), s.patchEventHandler())
+	api.DELETE("/events/:event_id", s.idempotent(), s.deleteEventV2Handler())
+	api.POST("/events/:event_id/restore", s.idempotent(), s.restoreEventHandler())
+	api.GET("/events/:event_id/history", s.historyHandler())
+	api.POST("/events/:event_id/revert", s.idempotent(), s.revertEventHandler())
+	api.GET("/users/:user_id/events", s.userEventsV2Handler())
+	api.GET("/users/:user_id/trash", s.trashHandler())
+	api.GET("/admin/audit", s.adminOnly(), s.auditHandler())
+	api.POST("/graphql", gin.WrapH(graph.NewHandler(s.ctx, s.srv)))
+}
+
+func (s *CalendarServer) createEventV2Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		var request *models.Event
+		if err := c.ShouldBindJSON(&request); err != nil {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "request_body",
+				Message: "invalid JSON format",
+			})
+			return
+		}
+		id, err := s.actingService(c).CreateEvent(request)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.Header("Location", "/api/v2/events/"+id)
+		c.Header("ETag", etag(request.Version))
+		c.JSON(http.StatusCreated, request)
+	}
+}
+
+func (s *CalendarServer) getEventV2Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		event, err := s.srv.GetEvent(c.Param("event_id"), c.Query("user_id"))
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		tag := etag(event.Version)
+		c.Header("ETag", tag)
+		if c.GetHeader("If-None-Match") == tag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.JSON(http.StatusOK, event)
+	}
+}
+
+// putEventV2Handler replaces the event. The ID in the path wins over the one
+// in the body.
+func (s *CalendarServer) putEventV2Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		var request *models.Event
+		if err := c.ShouldBindJSON(&request); err != nil {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "request_body",
+				Message: "invalid JSON format",
+			})
+			return
+		}
+		eventID := c.Param("event_id")
+		if request.EventID != "" && request.EventID != eventID {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "event_id",
+				Message: "must match the event in the path",
+			})
+			return
+		}
+		request.EventID = eventID
+		version, err := expectedVersion(c, request.Version)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		request.Version = version
+		if err := s.actingService(c).UpdateEvent(request); err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.Header("ETag", etag(request.Version))
+		c.JSON(http.StatusOK, request)
+	}
+}
+
+// deleteEventV2Handler deletes the event. The expected version comes from
+// If-Match or the version query parameter.
+func (s *CalendarServer) deleteEventV2Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		var queryVersion int64
+		if v := c.Query("version"); v != "" {
+			var err error
+			if queryVersion, err = strconv.ParseInt(v, 10, 64); err != nil {
+				s.handleError(c, &errors.ValidationError{
+					Field:   "version",
+					Message: "must be a number",
+				})
+				return
+			}
+		}
+		version, err := expectedVersion(c, queryVersion)
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		if err := s.actingService(c).DeleteEvent(c.Param("event_id"), version); err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// userEventsV2Handler lists the events of the user's calendar between from
+// and to, with the filters and pagination of GET /events.
+func (s *CalendarServer) userEventsV2Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		userID := c.Param("user_id")
+		page, err := s.srv.QueryEvents(&models.EventQueryParams{
+			ViewerID:    userID,
+			CalendarIDs: []string{userID},
+			From:        c.Query("from"),
+			To:          c.Query("to"),
+			Text:        c.Query("q"),
+			Statuses:    queryList(c, "status"),
+			Tags:        queryList(c, "tags"),
+			Sort:        c.Query("sort"),
+			Cursor:      c.Query("cursor"),
+			Limit:       c.Query("limit"),
+		})
+		if err != nil {
+			s.handleError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
@@ -0,0 +1,298 @@
+package transport
+
+import (
+	"Calendar/internal/errors"
+	"Calendar/internal/eventbus"
+	"Calendar/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	wsSendBuffer       = 256
+	wsWriteWait        = 10 * time.Second
+	wsPongWait         = 60 * time.Second
+	wsPingPeriod       = wsPongWait * 9 / 10
+	wsMaxMessageSize   = 4096
+	wsMaxSubscriptions = 50
+)
+
+// Message types of the live-update socket. A calendar is identified by the id
+// of the user who owns it.
+const (
+	wsSubscribe    = "subscribe"
+	wsUnsubscribe  = "unsubscribe"
+	wsPing         = "ping"
+	wsPong         = "pong"
+	wsSubscribed   = "subscribed"
+	wsUnsubscribed = "unsubscribed"
+	wsChange       = "change"
+	wsPresence     = "presence"
+	wsError        = "error"
+)
+
+type wsMessage struct {
+	Type       string              `json:"type"`
+	CalendarID string              `json:"calendar_id,omitempty"`
+	Change     *models.DomainEvent `json:"change,omitempty"`
+	Viewers    []string            `json:"viewers,omitempty"`
+	Message    string              `json:"message,omitempty"`
+}
+
+type wsClient struct {
+	userID    string
+	conn      *websocket.Conn
+	send      chan *wsMessage
+	closed    chan struct{}
+	closeOnce sync.Once
+	slow      atomic.Bool
+	mu        sync.RWMutex
+	calendars map[string]struct{}
+}
+
+func newWSClient(userID string, conn *websocket.Conn) *wsClient {
+	return &wsClient{
+		userID:    userID,
+		conn:      conn,
+		send:      make(chan *wsMessage, wsSendBuffer),
+		closed:    make(chan struct{}),
+		calendars: make(map[string]struct{}),
+	}
+}
+
+// enqueue never blocks: a client whose send buffer is full is too slow to keep
+// up and gets disconnected.
+func (c *wsClient) enqueue(msg *wsMessage) {
+	select {
+	case <-c.closed:
+		return
+	default:
+	}
+	select {
+	case c.send <- msg:
+	default:
+		c.slow.Store(true)
+		c.close()
+	}
+}
+
+func (c *wsClient) close() {
+	c.closeOnce.Do(func() { close(c.closed) })
+}
+
+func (c *wsClient) watches(event *models.DomainEvent) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.calendars[event.UserID]
+	return ok
+}
+
+func (c *wsClient) subscribedCalendars() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ids := make([]string, 0, len(c.calendars))
+	for id := range c.calendars {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// presenceHub keeps track of which clients are viewing which calendar.
+type presenceHub struct {
+	mu      sync.Mutex
+	viewers map[string]map[*wsClient]struct{}
+}
+
+func newPresenceHub() *presenceHub {
+	return &presenceHub{
+		viewers: make(map[string]map[*wsClient]struct{}),
+	}
+}
+
+func (h *presenceHub) join(calendarID string, c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.viewers[calendarID] == nil {
+		h.viewers[calendarID] = make(map[*wsClient]struct{})
+	}
+	h.viewers[calendarID][c] = struct{}{}
+	h.broadcast(calendarID)
+}
+
+func (h *presenceHub) leave(calendarID string, c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.viewers[calendarID], c)
+	if len(h.viewers[calendarID]) == 0 {
+		delete(h.viewers, calendarID)
+		return
+	}
+	h.broadcast(calendarID)
+}
+
+// broadcast must be called with mu held.
+func (h *presenceHub) broadcast(calendarID string) {
+	seen := make(map[string]struct{})
+	viewers := []string{}
+	for c := range h.viewers[calendarID] {
+		if _, ok := seen[c.userID]; ok {
+			continue
+		}
+		seen[c.userID] = struct{}{}
+		viewers = append(viewers, c.userID)
+	}
+	sort.Strings(viewers)
+	for c := range h.viewers[calendarID] {
+		c.enqueue(&wsMessage{Type: wsPresence, CalendarID: calendarID, Viewers: viewers})
+	}
+}
+
+// websocketHandler upgrades the connection to a live-update socket. Clients
+// subscribe to calendars and receive their changes together with the list of
+// users currently viewing them.
+func (s *CalendarServer) websocketHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error1"})
+				return
+			}
+		}()
+		if c.Request.Method != http.MethodGet {
+			c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+			return
+		}
+		userID := c.Query("user_id")
+		if userID == "" {
+			s.handleError(c, &errors.ValidationError{
+				Field:   "user_id",
+				Message: "can't be empty",
+			})
+			return
+		}
+		conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			return
+		}
+		client := newWSClient(userID, conn)
+		sub := s.changes.Subscribe("websocket", wsSendBuffer, client.watches)
+		writerDone := make(chan struct{})
+		go func() {
+			defer close(writerDone)
+			s.writeWS(client, sub)
+		}()
+		s.readWS(client)
+
+		client.close()
+		sub.Close()
+		for _, calendarID := range client.subscribedCalendars() {
+			s.presence.leave(calendarID, client)
+		}
+		<-writerDone
+		conn.Close()
+	}
+}
+
+func (s *CalendarServer) readWS(client *wsClient) {
+	client.conn.SetReadLimit(wsMaxMessageSize)
+	_ = client.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	client.conn.SetPongHandler(func(string) error {
+		return client.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		var msg wsMessage
+		if err := client.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		_ = client.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		switch msg.Type {
+		case wsSubscribe:
+			if msg.CalendarID == "" {
+				client.enqueue(&wsMessage{Type: wsError, Message: "calendar_id can't be empty"})
+				continue
+			}
+			client.mu.Lock()
+			_, already := client.calendars[msg.CalendarID]
+			full := len(client.calendars) >= wsMaxSubscriptions
+			if !already && !full {
+				client.calendars[msg.CalendarID] = struct{}{}
+			}
+			client.mu.Unlock()
+			if full && !already {
+				client.enqueue(&wsMessage{Type: wsError, CalendarID: msg.CalendarID, Message: "too many subscriptions"})
+				continue
+			}
+			client.enqueue(&wsMessage{Type: wsSubscribed, CalendarID: msg.CalendarID})
+			if !already {
+				s.presence.join(msg.CalendarID, client)
+			}
+		case wsUnsubscribe:
+			client.mu.Lock()
+			_, ok := client.calendars[msg.CalendarID]
+			delete(client.calendars, msg.CalendarID)
+			client.mu.Unlock()
+			client.enqueue(&wsMessage{Type: wsUnsubscribed, CalendarID: msg.CalendarID})
+			if ok {
+				s.presence.leave(msg.CalendarID, client)
+			}
+		case wsPing:
+			client.enqueue(&wsMessage{Type: wsPong})
+		default:
+			client.enqueue(&wsMessage{Type: wsError, Message: "unknown message type"})
+		}
+	}
+}
+
+func (s *CalendarServer) writeWS(client *wsClient, sub *eventbus.Subscription) {
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+	for {
+		var msg *wsMessage
+		select {
+		case <-client.closed:
+			if client.slow.Load() {
+				s.closeWS(client, websocket.CloseTryAgainLater, "client too slow")
+			} else {
+				s.closeWS(client, websocket.CloseNormalClosure, "")
+			}
+			return
+		case <-s.done:
+			s.closeWS(client, websocket.CloseGoingAway, "server shutting down")
+			return
+		case <-sub.Lagged:
+			s.closeWS(client, websocket.CloseTryAgainLater, "client too slow")
+			return
+		case <-ping.C:
+			_ = client.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				client.close()
+				return
+			}
+			continue
+		case change := <-sub.C:
+			view := change.ViewFor(client.userID)
+			if view == nil {
+				continue
+			}
+			msg = &wsMessage{Type: wsChange, CalendarID: change.UserID, Change: view}
+		case msg = <-client.send:
+		}
+		_ = client.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if err := client.conn.WriteJSON(msg); err != nil {
+			client.close()
+			return
+		}
+	}
+}
+
+func (s *CalendarServer) closeWS(client *wsClient, code int, reason string) {
+	client.close()
+	_ = client.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteWait))
+	// Unblock the reader so the handler can finish.
+	_ = client.conn.SetReadDeadline(time.Now())
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS scheduled_jobs;
+DROP TABLE IF EXISTS reminders;
+ALTER TABLE events DROP COLUMN IF EXISTS start_time;
@@ -0,0 +1,25 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS start_time TIME;
+
+CREATE TABLE IF NOT EXISTS reminders (
+    reminder_id VARCHAR(255) PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL REFERENCES events (event_id) ON DELETE CASCADE,
+    offset_minutes INTEGER NOT NULL,
+    channel VARCHAR(32) NOT NULL,
+    target VARCHAR(1024) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS reminders_event_id_idx ON reminders (event_id);
+
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+    job_id VARCHAR(255) PRIMARY KEY,
+    reminder_id VARCHAR(255) NOT NULL UNIQUE REFERENCES reminders (reminder_id) ON DELETE CASCADE,
+    run_at TIMESTAMPTZ NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_jobs_due_idx ON scheduled_jobs (run_at) WHERE status IN ('pending', 'processing');
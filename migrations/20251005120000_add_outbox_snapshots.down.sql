@@ -0,0 +1,7 @@
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS payload JSONB;
+
+UPDATE outbox SET payload = COALESCE(after, before, '{}'::jsonb);
+
+ALTER TABLE outbox ALTER COLUMN payload SET NOT NULL;
+ALTER TABLE outbox DROP COLUMN IF EXISTS before;
+ALTER TABLE outbox DROP COLUMN IF EXISTS after;
@@ -0,0 +1,7 @@
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS before JSONB;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS after JSONB;
+
+UPDATE outbox SET after = payload WHERE event_type IN ('event.created', 'event.updated');
+UPDATE outbox SET before = payload WHERE event_type = 'event.deleted';
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS payload;
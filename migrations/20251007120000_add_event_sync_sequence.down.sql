@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS event_tombstones;
+DROP TABLE IF EXISTS user_change_sequences;
+DROP INDEX IF EXISTS events_user_id_change_seq_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS change_seq;
+ALTER TABLE events DROP COLUMN IF EXISTS created_seq;
@@ -0,0 +1,19 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS created_seq BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS change_seq BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS events_user_id_change_seq_idx ON events (user_id, change_seq);
+
+CREATE TABLE IF NOT EXISTS user_change_sequences (
+    user_id VARCHAR(255) PRIMARY KEY,
+    value BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS event_tombstones (
+    event_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    change_seq BIGINT NOT NULL,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS event_tombstones_user_id_change_seq_idx ON event_tombstones (user_id, change_seq);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS events_user_id_date_idx ON events (user_id, date, event_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS events_search_vector_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS search_vector;
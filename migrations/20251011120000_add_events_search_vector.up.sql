@@ -0,0 +1,4 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian', event) || to_tsvector('english', event)) STORED;
+
+CREATE INDEX IF NOT EXISTS events_search_vector_idx ON events USING GIN (search_vector);
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id VARCHAR(255) PRIMARY KEY,
+    week_start VARCHAR(16) NOT NULL DEFAULT 'monday'
+);
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS events_search_vector_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE events ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian', event) || to_tsvector('english', event)) STORED;
+
+CREATE INDEX IF NOT EXISTS events_search_vector_idx ON events USING GIN (search_vector);
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS transparency,
+    DROP COLUMN IF EXISTS visibility,
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS color,
+    DROP COLUMN IF EXISTS url,
+    DROP COLUMN IF EXISTS location,
+    DROP COLUMN IF EXISTS description;
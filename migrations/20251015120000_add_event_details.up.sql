@@ -0,0 +1,22 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS location VARCHAR(1024) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS url VARCHAR(2048) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS color VARCHAR(7) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'confirmed'
+        CHECK (status IN ('confirmed', 'tentative', 'cancelled')),
+    ADD COLUMN IF NOT EXISTS visibility VARCHAR(16) NOT NULL DEFAULT 'public'
+        CHECK (visibility IN ('public', 'private', 'busy')),
+    ADD COLUMN IF NOT EXISTS transparency VARCHAR(16) NOT NULL DEFAULT 'opaque'
+        CHECK (transparency IN ('opaque', 'transparent'));
+
+DROP INDEX IF EXISTS events_search_vector_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE events ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', event) || to_tsvector('english', event), 'A') ||
+        setweight(to_tsvector('russian', location) || to_tsvector('english', location), 'B') ||
+        setweight(to_tsvector('russian', description) || to_tsvector('english', description), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS events_search_vector_idx ON events USING GIN (search_vector);
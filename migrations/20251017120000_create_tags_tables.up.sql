@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS tags (
+    tag_id VARCHAR(255) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tags_user_id_name_idx ON tags (user_id, lower(name));
+
+CREATE TABLE IF NOT EXISTS event_tags (
+    event_id VARCHAR(255) NOT NULL REFERENCES events (event_id) ON DELETE CASCADE,
+    tag_id VARCHAR(255) NOT NULL REFERENCES tags (tag_id) ON DELETE CASCADE,
+    PRIMARY KEY (event_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS event_tags_tag_id_idx ON event_tags (tag_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS event_attendees;
+ALTER TABLE events DROP COLUMN IF EXISTS end_time;
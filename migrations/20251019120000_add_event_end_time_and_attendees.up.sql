@@ -0,0 +1,9 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS end_time TIME;
+
+CREATE TABLE IF NOT EXISTS event_attendees (
+    event_id VARCHAR(255) NOT NULL REFERENCES events (event_id) ON DELETE CASCADE,
+    attendee VARCHAR(255) NOT NULL,
+    PRIMARY KEY (event_id, attendee)
+);
+
+CREATE INDEX IF NOT EXISTS event_attendees_attendee_idx ON event_attendees (attendee);
@@ -0,0 +1,3 @@
+DELETE FROM events WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS events_trash_idx;
+ALTER TABLE events DROP COLUMN IF EXISTS deleted_at;
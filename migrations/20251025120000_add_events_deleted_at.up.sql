@@ -0,0 +1,3 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS events_trash_idx ON events (user_id, deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS event_history (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    revision INTEGER NOT NULL,
+    version BIGINT NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    changes JSONB NOT NULL,
+    reverted_to INTEGER,
+    snapshot JSONB NOT NULL,
+    UNIQUE (event_id, revision)
+);
+
+CREATE INDEX IF NOT EXISTS event_history_actor_idx ON event_history (actor, id);
+CREATE INDEX IF NOT EXISTS event_history_user_id_idx ON event_history (user_id, id);
+CREATE INDEX IF NOT EXISTS event_history_changed_at_idx ON event_history (changed_at);
+
+CREATE OR REPLACE FUNCTION event_history_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'event_history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER event_history_append_only BEFORE UPDATE OR DELETE ON event_history
+    FOR EACH ROW EXECUTE FUNCTION event_history_append_only();
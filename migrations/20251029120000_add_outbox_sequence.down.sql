@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS outbox_unprocessed_user_id_seq_idx;
+DROP INDEX IF EXISTS outbox_user_id_seq_idx;
+ALTER TABLE outbox DROP COLUMN IF EXISTS seq;
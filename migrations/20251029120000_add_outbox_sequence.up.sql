@@ -0,0 +1,14 @@
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS seq BIGINT;
+
+UPDATE outbox SET seq = numbered.seq
+FROM (SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY id) AS seq FROM outbox) numbered
+WHERE outbox.id = numbered.id;
+
+INSERT INTO user_change_sequences (user_id, value)
+SELECT user_id, max(seq) FROM outbox GROUP BY user_id
+ON CONFLICT (user_id) DO UPDATE SET value = GREATEST(user_change_sequences.value, EXCLUDED.value);
+
+ALTER TABLE outbox ALTER COLUMN seq SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS outbox_user_id_seq_idx ON outbox (user_id, seq);
+CREATE INDEX IF NOT EXISTS outbox_unprocessed_user_id_seq_idx ON outbox (user_id, seq) WHERE processed_at IS NULL;
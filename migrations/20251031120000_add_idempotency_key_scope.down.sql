@@ -0,0 +1,6 @@
+-- Keys of different callers may share a key, so only the unscoped ones are kept.
+DELETE FROM idempotency_keys WHERE scope <> '';
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS scope;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (key);
@@ -0,0 +1,6 @@
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS scope TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (scope, key);
+
+ALTER TABLE idempotency_keys ALTER COLUMN scope DROP DEFAULT;
@@ -3,7 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Config struct {
@@ -14,16 +14,20 @@ type Config struct {
 	Password string `yaml:"postgres_password" env:"POSTGRES_PASSWORD" env-default:"1234"`
 }
 
-func New(config Config) (*pgx.Conn, error) {
+func New(config Config) (*pgxpool.Pool, error) {
 	connString := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
 		config.User,
 		config.Password,
 		config.Host,
 		config.Port,
 		config.Database)
-	conn, err := pgx.Connect(context.Background(), connString)
+	pool, err := pgxpool.New(context.Background(), connString)
 	if err != nil {
 		return nil, fmt.Errorf("unable to connect to database: %w", err)
 	}
-	return conn, nil
+	if err := pool.Ping(context.Background()); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
+	}
+	return pool, nil
 }